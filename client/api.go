@@ -2,29 +2,58 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"discord-delete/client/spoof"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 const api = "https://discord.com/api/v8"
 const messageLimit = 25
+const defaultMaxRetryAfter = 5 * time.Minute
+
+// How long to wait between deleting messages
+// A delay which is too short will cause the server to return 429 and force us to wait a while
+// By preempting the server's delay, we can reduce the number of requests made to the server
+const minSleep = 200 * time.Millisecond
+
+// How long to give the search index to settle before searching again
+var settleDelay = 2 * time.Second
+
+// How long to wait before the first retry after a server error, doubling each time
+var serverBackoff = time.Second
+
+const defaultServerRetries = 3
 
 var endpoints = map[string]string{
 	"me":             "/users/@me",
+	"login":          "/auth/login",
+	"mfa_totp":       "/auth/mfa/totp",
 	"relationships":  "/users/@me/relationships",
+	"read_states":    "/users/@me/read-states",
 	"guilds":         "/users/@me/guilds",
+	"guild":          "/guilds/%v",
 	"guild_channels": "/guilds/%v/channels",
+	"guild_threads":  "/guilds/%v/threads/active",
+	"archived_threads": "/channels/%v/threads/archived/public" +
+		"?limit=100",
 	"guild_msgs": "/guilds/%v/messages/search" +
 		"?include_nsfw=true" +
 		"&author_id=%v" +
 		"&offset=%v" +
 		"&limit=%v",
 	"channels": "/users/@me/channels",
+	"channel":  "/channels/%v",
 	"channel_msgs": "/channels/%v/messages/search" +
 		"?include_nsfw=true" +
 		"&author_id=%v" +
@@ -34,86 +63,397 @@ var endpoints = map[string]string{
 }
 
 type Client struct {
-	deletedCount int
-	requestCount int
-	token        string
-	spoof        spoof.Info
-	dryRun       bool
-	maxID        int64
-	minID        int64
-	skipChannels []string
-	httpClient   http.Client
+	// Counters are updated atomically so that channels can be processed concurrently
+	deletedCount        int64
+	requestCount        int64
+	foundCount          int64
+	steppedOver         int64
+	unreadPages         int64
+	passCount           int64
+	forbiddenCount      int64
+	authorized          int32
+	slowdown            int32
+	failedRelations     []string
+	relationsForbidden  bool
+	relationOutcomes    map[string]string
+	unhandledRelations  []string
+	inaccessibleGuilds  []string
+	fallbackGuilds      []string
+	unsearchableGuilds  []string
+	timings             *timingHistogram
+	export              *exporter
+	archive             *archiver
+	global              *rateLimiter
+	types               *typeCounter
+	routes              *routeCounter
+	systemChannels      *channelSet
+	missingChannels     *channelSet
+	disabledChannels    *channelSet
+	forbiddenChannels   *channelSet
+	refusedChannels     *channelSet
+	visitedChannels     *channelSet
+	fieldWarnings       *fieldWarnings
+	inFlight            chan struct{}
+	timestamps          *timestamps
+	plan                *planFile
+	progress            *channelProgress
+	rate                *deletionRate
+	checkpoint          *Checkpoint
+	control             *Control
+	ctx                 context.Context
+	minLength           int
+	maxLength           int
+	mentions            map[string]bool
+	protectWords        []string
+	editedOnly          bool
+	hours               map[int]bool
+	days                map[time.Weekday]bool
+	location            *time.Location
+	skipRepliedTo       bool
+	readFilter          string
+	readStates          *readStates
+	repliesTo           string
+	linksOnly           bool
+	webhooks            string
+	replies             string
+	forbidden           string
+	baseURL             string
+	headers             http.Header
+	token               string
+	spoof               spoof.Info
+	dryRun              bool
+	bestEffort          bool
+	skipRelationships   bool
+	relationshipTypes   map[int]bool
+	noReopenDMs         bool
+	noRelationDedup     bool
+	strictFields        bool
+	startPhase          string
+	guilds              []string
+	perChannelGuildScan bool
+	maxRetryAfter       time.Duration
+	networkWait         time.Duration
+	channelCooldown     time.Duration
+	batchSize           int
+	batchCooldown       time.Duration
+	slowmodeDelay       time.Duration
+	emptyPageRetries    int
+	maxPerChannel       int
+	oldestPercent       float64
+	maxDeletions        int64
+	serverRetries       int
+	logEvery            int
+	strategy            string
+	manifestPath        string
+	markerDir           string
+	force               bool
+	forceAccount        bool
+	dormantAge          time.Duration
+	maxID               int64
+	minID               int64
+	skipChannels        []string
+	skipSystem          bool
+	cautious            bool
+	httpClient          http.Client
 }
 
 func New(token string) (c Client) {
 	return Client{
-		token:      token,
-		spoof:      spoof.RandomInfo(),
-		httpClient: http.Client{},
+		token:             token,
+		spoof:             spoof.RandomInfo(),
+		httpClient:        newHTTPClient(),
+		timings:           newTimingHistogram(),
+		global:            newRateLimiter(),
+		types:             newTypeCounter(),
+		routes:            newRouteCounter(),
+		systemChannels:    newChannelSet(),
+		missingChannels:   newChannelSet(),
+		disabledChannels:  newChannelSet(),
+		forbiddenChannels: newChannelSet(),
+		refusedChannels:   newChannelSet(),
+		visitedChannels:   newChannelSet(),
+		fieldWarnings:     newFieldWarnings(),
+		inFlight:          make(chan struct{}, defaultMaxInFlight),
+		forbidden:         ForbiddenSkip,
+		progress:          newChannelProgress(),
+		rate:              newDeletionRate(),
+		baseURL:           api,
+		maxRetryAfter:     defaultMaxRetryAfter,
+		serverRetries:     defaultServerRetries,
+		logEvery:          1,
+		strategy:          StrategyOffset,
+		startPhase:        PhaseChannels,
+		skipSystem:        true,
 	}
 }
 
-func (c *Client) PartialDelete() error {
+func newHTTPClient() http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		// We only ever talk to a single host, and requests are made one after another
+		// Keeping a handful of idle connections around means we never have to pay for
+		// a fresh TCP and TLS handshake between requests
+		// The default of 2 idle connections per host is enough for sequential requests,
+		// but leaves no headroom for retries racing a connection being closed
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 10,
+		// Rate limit sleeps can run for several seconds, so hold idle connections open
+		// for longer than that to avoid reconnecting after every 429
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// A custom DialContext disables HTTP/2 unless we explicitly ask for it
+		// Discord serves the API over HTTP/2, which multiplexes requests over one connection
+		ForceAttemptHTTP2: true,
+	}
+
+	return http.Client{
+		Transport: transport,
+	}
+}
+
+// profile fetches our profile at the start of a run, checking that any checkpoint
+// being resumed belongs to the same account
+func (c *Client) profile() (*Me, error) {
 	me, err := c.Me()
 	if err != nil {
-		return errors.Wrap(err, "Error fetching profile information")
+		return nil, errors.Wrap(err, "Error fetching profile information")
+	}
+
+	err = c.checkAccount(me)
+	if err != nil {
+		return nil, err
+	}
+
+	c.loadReadStates()
+
+	return me, nil
+}
+
+func (c *Client) PartialDelete() (err error) {
+	defer func() {
+		c.logProgress(err)
+	}()
+
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	if c.alreadyClean(me) {
+		return nil
+	}
+
+	err = c.writeManifest()
+	if err != nil {
+		return err
 	}
 
 	channels, err := c.Channels()
 	if err != nil {
 		return errors.Wrap(err, "Error fetching channels")
 	}
+	sortChannels(channels)
 
-	for _, channel := range channels {
-		err = c.DeleteFromChannel(me, &channel)
+	if c.includesPhase(PhaseChannels) {
+		for _, channel := range channels {
+			err = c.DeleteFromChannel(me, &channel)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.skipRelationships {
+		log.Infof("Skipping resolving relationships to channels")
+	} else if c.includesPhase(PhaseRelationships) {
+		err = c.DeleteFromRelationships(me, channels)
 		if err != nil {
 			return err
 		}
 	}
 
+	guilds, err := c.Guilds()
+	if err != nil {
+		return errors.Wrap(err, "Error fetching guilds")
+	}
+	sortChannels(guilds)
+	for _, guild := range guilds {
+		if !c.targetGuild(guild.ID) {
+			log.Debugf("Skipping guild '%v' as it wasn't targeted", guild.Name)
+			continue
+		}
+
+		err = c.DeleteFromGuild(me, &guild)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logSummary()
+
+	err = c.markClean(me)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	return nil
+}
+
+func (c *Client) logSummary() {
+	found := atomic.LoadInt64(&c.foundCount)
+	passes := atomic.LoadInt64(&c.passCount)
+
+	switch {
+	case c.DeletedCount() > 0:
+		log.Infof("Finished deleting messages: %v deleted in %v total requests", c.DeletedCount(), c.RequestCount())
+	case found == 0:
+		log.Infof("No messages found to delete across %v channels", passes)
+	default:
+		log.Infof("Found %v messages across %v channels, but every one of them was skipped", found, passes)
+	}
+	if len(c.failedRelations) > 0 {
+		log.Warnf("Failed to resolve %v relationships: %v", len(c.failedRelations), strings.Join(c.failedRelations, ", "))
+	}
+	if len(c.relationOutcomes) > 0 {
+		log.Infof("Relationships: %v", c.relationSummary())
+	}
+	if len(c.unhandledRelations) > 0 {
+		log.Warnf("%v relationships were neither deleted from nor skipped, please report this: %v", len(c.unhandledRelations), strings.Join(c.unhandledRelations, ", "))
+	}
+	c.logForbidden()
+	if missing := c.missingChannels.list(); len(missing) > 0 {
+		log.Warnf("Skipped %v channels which no longer exist: %v", len(missing), strings.Join(missing, ", "))
+	}
+	if len(c.inaccessibleGuilds) > 0 {
+		log.Warnf("Skipped %v guilds which became inaccessible: %v", len(c.inaccessibleGuilds), strings.Join(c.inaccessibleGuilds, ", "))
+	}
+	if stepped := atomic.LoadInt64(&c.steppedOver); stepped > 0 {
+		log.Warnf("Stepped over %v search results the index was slow to update, run again to catch anything behind them", stepped)
+	}
+	if unread := atomic.LoadInt64(&c.unreadPages); unread > 0 {
+		log.Warnf("Couldn't read %v pages of search results, run again to catch anything in them", unread)
+	}
+	c.logSearchDisabled()
+	if c.RequestCount() > 0 {
+		log.Infof("Requests by route:")
+		stats := c.Stats()
+		stats.logRoutes()
+	}
+	c.timings.log()
+	c.types.log()
+}
+
+// DeleteFromRelationships resolves relationships to their DM channels, deleting from any
+// which weren't already found amongst the open channels
+func (c *Client) DeleteFromRelationships(me *Me, channels []Channel) error {
 	relationships, err := c.Relationships()
+	if hasStatus(err, http.StatusForbidden) {
+		// Some accounts aren't allowed to list their relationships, that shouldn't
+		// stop us from moving on to guilds, but their closed DMs went unchecked
+		log.Warnf("Relationships are unavailable for this account, skipping: %v", err)
+		c.relationsForbidden = true
+		return nil
+	}
 	if err != nil {
 		return errors.Wrap(err, "Error fetching relationships")
 	}
+	sort.SliceStable(relationships, func(i, j int) bool {
+		return olderID(relationships[i].ID, relationships[j].ID)
+	})
+
+	c.relationOutcomes = make(map[string]string)
 
 Relationships:
 	for _, relation := range relationships {
+		if !c.relationWanted(relation) {
+			c.relationOutcomes[relation.ID] = "skipped by type"
+			continue
+		}
+
+		open := false
 		for _, channel := range channels {
 			// If the relation is the sole recipient in one of the channels we found
 			// earlier, skip it.
-			if channel.Type == DirectChannel && channel.Recipients[0].ID == relation.ID {
+			if channel.Type == DirectChannel && len(channel.Recipients) == 1 && channel.Recipients[0].ID == relation.ID {
+				if c.noRelationDedup {
+					open = true
+					break
+				}
 				log.Debugf("Skipping resolving relation %v because the user already has the channel open", relation.ID)
+				c.relationOutcomes[relation.ID] = "already open"
 				continue Relationships
 			}
 		}
 
+		// Resolving the relationship opens the DM, which shows up in the Discord client
+		if c.noReopenDMs && !open {
+			log.Infof("Skipping closed DM with '%v'", relation.Recipient.Username)
+			c.relationOutcomes[relation.ID] = "left closed"
+			continue
+		}
+
 		channel, err := c.ChannelRelationship(&relation.Recipient)
 		if err != nil {
-			return errors.Wrap(err, "Error resolving relationship to channel")
+			if !c.bestEffort || tokenInvalidated(err) {
+				return errors.Wrap(err, "Error resolving relationship to channel")
+			}
+			log.Warnf("Failed to resolve relationship with '%v' to channel, continuing: %v", relation.Recipient.Username, err)
+			c.failedRelations = append(c.failedRelations, relation.Recipient.Username)
+			c.relationOutcomes[relation.ID] = "failed to resolve"
+			continue
 		}
 
 		log.Infof("Resolved relationship with '%v' to channel %v", relation.Recipient.Username, channel.ID)
+		if c.visitedChannels.has(channel.ID) {
+			c.relationOutcomes[relation.ID] = "already processed"
+			continue
+		}
 
 		err = c.DeleteFromChannel(me, channel)
 		if err != nil {
 			return err
 		}
+		if open {
+			c.relationOutcomes[relation.ID] = "resolved while open"
+		} else {
+			c.relationOutcomes[relation.ID] = "reopened"
+		}
 	}
 
-	guilds, err := c.Guilds()
-	if err != nil {
-		return errors.Wrap(err, "Error fetching guilds")
-	}
-	for _, guild := range guilds {
-		err = c.DeleteFromGuild(me, &guild)
-		if err != nil {
-			return err
+	c.verifyRelationships(relationships)
+
+	return nil
+}
+
+// verifyRelationships checks that every relationship ended up with an outcome, to
+// catch any the loop above lets slip through without either deleting or skipping
+func (c *Client) verifyRelationships(relationships []Relationship) {
+	for _, relation := range relationships {
+		if _, ok := c.relationOutcomes[relation.ID]; !ok {
+			c.unhandledRelations = append(c.unhandledRelations, relation.Recipient.String())
 		}
 	}
+}
+
+// relationSummary counts the relationships with each outcome, e.g. "2 already open, 1 reopened"
+func (c *Client) relationSummary() string {
+	counts := make(map[string]int)
+	for _, outcome := range c.relationOutcomes {
+		counts[outcome]++
+	}
 
-	log.Infof("Finished deleting messages: %v deleted in %v total requests", c.deletedCount, c.requestCount)
+	var outcomes []string
+	for outcome, count := range counts {
+		outcomes = append(outcomes, fmt.Sprintf("%v %v", count, outcome))
+	}
+	sort.Strings(outcomes)
 
-	return nil
+	return strings.Join(outcomes, ", ")
 }
 
 func (c *Client) DeleteFromChannel(me *Me, channel *Channel) error {
@@ -122,19 +462,114 @@ func (c *Client) DeleteFromChannel(me *Me, channel *Channel) error {
 		return nil
 	}
 
+	// The same DM can be reached both as an open channel and through a relationship
+	if !c.visitedChannels.claim(channel.ID) {
+		log.Debugf("Skipping channel %v, it was already processed in this run", channel.ID)
+		return nil
+	}
+
+	if !c.readStateMatches(channel.ID) {
+		log.Infof("Skipping channel %v because of its read state", channel.ID)
+		return nil
+	}
+
+	cursor, done := c.resumeFrom(channel.ID)
+	if done {
+		log.Infof("Skipping channel %v, it was finished in a previous run", channel.ID)
+		return nil
+	}
+
+	active, err := c.active("channel_msgs", channel, me)
+	if c.channelGone(channel, err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error fetching newest message for channel")
+	}
+	if active {
+		log.Infof("Skipping message deletion for active channel %v", channel.ID)
+		return nil
+	}
+
+	cutoff, err := c.oldestCutoff("channel_msgs", channel, me)
+	if c.channelGone(channel, err) || c.channelForbidden(channel, err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error finding the oldest messages for channel")
+	}
+
+	err = c.startPass()
+	if err != nil {
+		return err
+	}
+
 	seek := 0
+	retries := 0
+	repeats := 0
+	pages := newPageTracker()
+	pages.author = me.ID
+	pages.cursor = cursor
+	pages.boundOldest(cutoff)
+	pages.extraDelay = c.slowmode(channel)
 
 	for {
-		results, err := c.ChannelMessages(channel, me, &seek)
+		results, err := c.ChannelMessages(channel, me, &seek, pages.cursor)
+		if c.channelGone(channel, err) || c.channelForbidden(channel, err) {
+			return nil
+		}
 		if err != nil {
 			return errors.Wrap(err, "Error fetching messages for channel")
 		}
+		if nothingAuthored(results, pages) {
+			log.Infof("Channel %v: no messages, skipping", channel.ID)
+			return c.checkpointDone(channel.ID)
+		}
 		if len(results.ContextMessages) == 0 {
+			settling, err := c.indexSettling(results, seek, &retries)
+			if err != nil {
+				return err
+			}
+			if settling {
+				continue
+			}
 			log.Infof("No more messages to delete for channel %v", channel.ID)
+			err = c.checkpointFinished(channel.ID, results, seek)
+			if err != nil {
+				return err
+			}
 			break
 		}
+		retries = 0
+
+		// If we've been handed the same page again, our deletions aren't being reflected
+		// in the results yet, so give the index a chance to catch up
+		if pages.repeated(results) {
+			err = c.pageRepeated(results, &seek, &repeats)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		repeats = 0
+
+		err = c.DeleteMessages(results, &seek, pages)
+		if err != nil {
+			return err
+		}
+		// Stop as soon as a limit is reached, rather than searching for another page first
+		if pages.limited || c.atLimit(pages) {
+			return c.stopAtLimit(channel, pages)
+		}
+
+		err = c.checkpointPage(channel.ID, results)
+		if err != nil {
+			return err
+		}
+
+		c.advance(results, &seek, pages)
 
-		err = c.DeleteMessages(results, &seek)
+		err = c.betweenPages()
 		if err != nil {
 			return err
 		}
@@ -149,33 +584,325 @@ func (c *Client) DeleteFromGuild(me *Me, channel *Channel) error {
 		return nil
 	}
 
+	c.findSystemChannels(channel)
+
+	cursor, done := c.resumeFrom(channel.ID)
+	if done {
+		log.Infof("Skipping guild '%v', it was finished in a previous run", channel.Name)
+		return nil
+	}
+
+	active, err := c.active("guild_msgs", channel, me)
+	if err != nil {
+		return errors.Wrap(err, "Error fetching newest message for guild")
+	}
+	if active {
+		log.Infof("Skipping message deletion for active guild '%v'", channel.Name)
+		return nil
+	}
+
+	cutoff, err := c.oldestCutoff("guild_msgs", channel, me)
+	if searchDisabled(err) {
+		return c.searchDisabledFallback(me, channel)
+	}
+	if hasStatus(err, http.StatusForbidden, http.StatusNotFound) {
+		log.Warnf("Guild '%v' is no longer accessible, skipping", channel.Name)
+		c.inaccessibleGuilds = append(c.inaccessibleGuilds, channel.Name)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error finding the oldest messages for guild")
+	}
+
+	err = c.startPass()
+	if err != nil {
+		return err
+	}
+
 	seek := 0
+	retries := 0
+	repeats := 0
+	warmupRetries := 0
+	pages := newPageTracker()
+	pages.author = me.ID
+	pages.cursor = cursor
+	pages.boundOldest(cutoff)
 
 	for {
-		results, err := c.GuildMessages(channel, me, &seek)
+		results, err := c.GuildMessages(channel, me, &seek, pages.cursor)
+		if searchDisabled(err) {
+			return c.searchDisabledFallback(me, channel)
+		}
+		if hasStatus(err, http.StatusForbidden, http.StatusNotFound) {
+			// We've most likely left or been removed from the guild since the run started
+			log.Warnf("Guild '%v' is no longer accessible, skipping", channel.Name)
+			c.inaccessibleGuilds = append(c.inaccessibleGuilds, channel.Name)
+			return nil
+		}
 		if err != nil {
 			return errors.Wrap(err, "Error fetching messages for guild")
 		}
+		if nothingAuthored(results, pages) {
+			warming, err := c.warmingUp(channel, pages, &warmupRetries)
+			if err != nil {
+				return err
+			}
+			if warming {
+				continue
+			}
+			log.Infof("Guild '%v': no messages, skipping", channel.Name)
+			err = c.checkpointDone(channel.ID)
+			if err != nil {
+				return err
+			}
+			break
+		}
 		if len(results.ContextMessages) == 0 {
+			settling, err := c.indexSettling(results, seek, &retries)
+			if err != nil {
+				return err
+			}
+			if settling {
+				continue
+			}
+			warming, err := c.warmingUp(channel, pages, &warmupRetries)
+			if err != nil {
+				return err
+			}
+			if warming {
+				continue
+			}
 			log.Infof("No more messages to delete for guild '%v'", channel.Name)
+			err = c.checkpointFinished(channel.ID, results, seek)
+			if err != nil {
+				return err
+			}
 			break
 		}
+		retries = 0
 
-		err = c.DeleteMessages(results, &seek)
+		// If we've been handed the same page again, our deletions aren't being reflected
+		// in the results yet, so give the index a chance to catch up
+		if pages.repeated(results) {
+			err = c.pageRepeated(results, &seek, &repeats)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		repeats = 0
+
+		err = c.DeleteMessages(results, &seek, pages)
+		if err != nil {
+			return err
+		}
+		// Stop as soon as a limit is reached, rather than searching for another page first
+		if pages.limited || c.atLimit(pages) {
+			return c.stopAtLimit(channel, pages)
+		}
+
+		err = c.checkpointPage(channel.ID, results)
+		if err != nil {
+			return err
+		}
+
+		c.advance(results, &seek, pages)
+
+		err = c.betweenPages()
 		if err != nil {
 			return err
 		}
 	}
 
+	if c.perChannelGuildScan {
+		return c.scanGuildChannels(me, channel)
+	}
+
 	return nil
 }
 
-func (c *Client) DeleteMessages(messages *Messages, seek *int) error {
-	// Milliseconds to wait between deleting messages
-	// A delay which is too short will cause the server to return 429 and force us to wait a while
-	// By preempting the server's delay, we can reduce the number of requests made to the server
-	const minSleep = 200
+// The guild wide search doesn't always cover every channel, so optionally follow it
+// up by searching each channel individually to catch anything it missed
+func (c *Client) scanGuildChannels(me *Me, guild *Channel) error {
+	channels, err := c.GuildChannels(guild)
+	if err != nil {
+		return errors.Wrap(err, "Error fetching guild channels")
+	}
+
+	searched := 0
+	var disabled error
+	for _, channel := range channels {
+		if channel.Type == GuildCategory || channel.Type == GuildDirectory {
+			continue
+		}
+
+		err = c.deleteFromGuildChannel(me, guild, &channel)
+		if searchDisabled(err) {
+			// Search can be off for some channels and not others, so carry on with the rest
+			log.Warnf("Search is disabled for channel '%v' in guild '%v', skipping it", channel.Name, guild.Name)
+			c.disabledChannels.add(channel.ID)
+			disabled = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		searched++
+	}
+
+	// Only if every channel turned us away is the guild a lost cause
+	if searched == 0 && disabled != nil {
+		return disabled
+	}
+
+	return nil
+}
+
+// deleteFromGuildChannel searches a single channel in a guild, however its type needs searching
+func (c *Client) deleteFromGuildChannel(me *Me, guild *Channel, channel *Channel) error {
+	switch channel.Type {
+	case GuildCategory, GuildDirectory:
+		// Categories only group other channels, and directories list other guilds, neither
+		// contain messages themselves
+		return nil
+	case GuildVoice, GuildStage:
+		// The text chat lives in the voice or stage channel itself, so it's searched the same way
+		log.Debugf("Scanning text chat of %v channel '%v' in guild '%v'", ChannelTypeName(channel.Type), channel.Name, guild.Name)
+		return c.DeleteFromChannel(me, channel)
+	case GuildForum, GuildMedia:
+		// Every post is a thread of its own, the forum itself can't be searched
+		log.Debugf("Scanning posts in forum '%v' in guild '%v'", channel.Name, guild.Name)
+		return c.deleteFromForum(me, guild, channel)
+	default:
+		log.Debugf("Scanning channel '%v' in guild '%v'", channel.Name, guild.Name)
+		return c.DeleteFromChannel(me, channel)
+	}
+}
+
+// The search index is eventually consistent, so on active accounts it can report
+// results which don't appear on the page yet. Rather than stopping early, we wait
+// a little while for it to settle before concluding there's nothing left.
+func (c *Client) indexSettling(results *Messages, seek int, retries *int) (bool, error) {
+	const maxRetries = 3
+
+	// Results we've seeked past are never going to show up again
+	if results.TotalResults <= seek || *retries >= maxRetries {
+		return false, nil
+	}
+
+	(*retries)++
+	log.Debugf("Search returned an empty page but reported %v results (analytics ID %v), retrying in %v", results.TotalResults, results.AnalyticsID, settleDelay)
+	err := c.sleep(settleDelay)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Guild searches can return an empty first page, reporting no results at all, while
+// the index for the guild warms up. warmingUp retries the first page a configurable
+// number of times before we conclude the guild is really empty.
+func (c *Client) warmingUp(guild *Channel, pages *pageTracker, retries *int) (bool, error) {
+	if pages.started() || *retries >= c.emptyPageRetries {
+		return false, nil
+	}
+
+	(*retries)++
+	log.Infof("Search for guild '%v' returned an empty first page, retrying in %v (%v/%v)", guild.Name, settleDelay, *retries, c.emptyPageRetries)
+	err := c.sleep(settleDelay)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// overLimit reports whether this run has deleted as many messages as it's allowed to
+// Only deletions made by this run count, not those made by runs it's resuming from
+func (c *Client) overLimit() bool {
+	return c.maxDeletions > 0 && c.DeletedCount() >= c.maxDeletions
+}
+
+// atLimit reports whether the run or the channel has had as many deletions as it's allowed
+func (c *Client) atLimit(pages *pageTracker) bool {
+	return c.overLimit() || (c.maxPerChannel > 0 && pages.deleted >= c.maxPerChannel)
+}
+
+// stopAtLimit checkpoints where a limited pass stopped, so the next run picks up from
+// there, and ends the run if it was the overall limit rather than the per-channel one
+func (c *Client) stopAtLimit(channel *Channel, pages *pageTracker) error {
+	err := c.checkpointAt(channel.ID, pages.lastDeleted)
+	if err != nil {
+		return err
+	}
+
+	if c.overLimit() {
+		log.Infof("Reached the limit of %v messages, stopping", c.maxDeletions)
+		return ErrorLimitReached
+	}
+
+	log.Infof("Reached the limit of %v messages for channel %v, moving on", c.maxPerChannel, channel.ID)
+	return nil
+}
 
+// channelGone reports whether searching a channel failed because it no longer exists,
+// e.g. it was deleted when a guild was restructured after we listed its channels
+// Channels we can't read are forbidden rather than missing, and never get here
+func (c *Client) channelGone(channel *Channel, err error) bool {
+	if !hasStatus(err, http.StatusNotFound) {
+		return false
+	}
+
+	log.Warnf("Channel %v no longer exists, skipping", channel.ID)
+	c.missingChannels.add(channel.ID)
+	return true
+}
+
+// startPass counts a pass over a channel, cooling down first unless it's the first one
+func (c *Client) startPass() error {
+	if atomic.AddInt64(&c.passCount, 1) > 1 && c.channelCooldown > 0 {
+		log.Debugf("Cooling down for %v before the next channel", c.channelCooldown)
+		err := c.sleep(c.channelCooldown)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.checkFlags()
+	return nil
+}
+
+// endBatch cools down once every batch of deletions, letting the rate limits recover
+func (c *Client) endBatch(deleted int64) error {
+	if c.batchSize <= 0 || deleted%int64(c.batchSize) != 0 {
+		return nil
+	}
+
+	log.Infof("Finished a batch of %v messages, %v deleted so far, cooling down for %v", c.batchSize, deleted, c.batchCooldown)
+	if c.dryRun {
+		return nil
+	}
+	return c.sleep(c.batchCooldown)
+}
+
+// advance moves on to the next page of results once the current page has been handled
+// The offset strategy has already had its seek index updated message by message
+func (c *Client) advance(results *Messages, seek *int, pages *pageTracker) {
+	if c.strategy != StrategyMaxID {
+		return
+	}
+
+	// Walk backwards from the oldest message on the page, which doesn't rely on
+	// the offset at all, so deletions can't shift the window underneath us
+	oldest := oldestHit(results)
+	if oldest > 0 {
+		pages.cursor = oldest
+	}
+	*seek = 0
+}
+
+func (c *Client) DeleteMessages(messages *Messages, seek *int, pages *pageTracker) error {
 	for _, ctx := range messages.ContextMessages {
 		for _, msg := range ctx {
 			if !msg.Hit {
@@ -185,9 +912,38 @@ func (c *Client) DeleteMessages(messages *Messages, seek *int) error {
 				continue
 			}
 
-			// The message might be an action rather than text. Actions aren't deletable.
-			// An example of an action is a call request.
-			if msg.Type != UserMessage && msg.Type != UserReply {
+			// Hits should all be ours, but never delete someone else's message on the
+			// strength of the search alone
+			if pages.author != "" && msg.Author.ID != pages.author {
+				log.Warnf("Search returned message %v by someone else, seeking ahead", msg.ID)
+				(*seek)++
+				continue
+			}
+
+			c.types.add(&msg)
+
+			// If a system message we already tried to delete shows up again, the server
+			// didn't actually remove it, so stop counting it and move past it
+			if pages.attempted[msg.ID] {
+				log.Infof("Message %v of type %v could not be deleted, seeking ahead", msg.ID, msg.Type)
+				delete(pages.attempted, msg.ID)
+				atomic.AddInt64(&c.deletedCount, -1)
+				(*seek)++
+				continue
+			}
+
+			// Pages can overlap when the index lags behind our deletions, don't process
+			// the same message twice
+			if pages.duplicate(msg.ID) {
+				log.Debugf("Message %v has already been processed, skipping", msg.ID)
+				continue
+			}
+			atomic.AddInt64(&c.foundCount, 1)
+
+			// The message might be an action rather than text. Most actions aren't deletable,
+			// see deletableTypes for the ones we attempt anyway.
+			if !deletableTypes[msg.Type] {
+				c.types.logMessage(&msg)
 				log.Debugf("Found message of type %v, seeking ahead", msg.Type)
 				(*seek)++
 				continue
@@ -204,26 +960,172 @@ func (c *Client) DeleteMessages(messages *Messages, seek *int) error {
 				continue
 			}
 
-			log.Infof("Deleting message %v from channel %v", msg.ID, msg.ChannelID)
+			// The server should already have applied our bounds, but don't rely on it
+			if !c.inBounds(msg.ID) {
+				log.Debugf("Message %v is outside of the requested bounds, seeking ahead", msg.ID)
+				(*seek)++
+				continue
+			}
+
+			if !c.wanted(&msg) {
+				log.Debugf("Message %v doesn't match the filters, seeking ahead", msg.ID)
+				(*seek)++
+				continue
+			}
+
+			if !c.readStateMatches(msg.ChannelID) {
+				log.Debugf("Message %v is in a channel excluded by its read state, seeking ahead", msg.ID)
+				(*seek)++
+				continue
+			}
+
+			if c.skipRepliedTo && repliedTo(&msg, messages) {
+				log.Debugf("Message %v has been replied to, seeking ahead", msg.ID)
+				(*seek)++
+				continue
+			}
+
+			if c.atLimit(pages) {
+				pages.limited = true
+				return nil
+			}
+
+			err := c.planAllows(&msg)
+			if err != nil {
+				return err
+			}
+
+			err = c.archiveRaw(&msg)
+			if err != nil {
+				return err
+			}
+
+			c.logDeletion(&msg)
+			var pause error
 			if c.dryRun {
 				// Move seek index forward to simulate message deletion on server's side
 				(*seek)++
 			} else {
 				err := c.DeleteMessage(&msg)
+				// Deleted elsewhere since the search found it, so it isn't ours to count
+				if hasStatus(err, http.StatusNotFound) {
+					log.Debugf("Message %v has already been deleted", msg.ID)
+					continue
+				}
+				// System messages are only attempted, so their refusal says nothing about the channel
+				if err != nil && isSystemMessage(msg.Type) && refused(err) {
+					log.Infof("Server refused to delete message %v of type %v, seeking ahead", msg.ID, msg.Type)
+					(*seek)++
+					continue
+				}
+				// Guild searches include channels we can read but not delete from
+				if c.deleteForbidden(&msg, err) {
+					(*seek)++
+					continue
+				}
 				if err != nil {
 					return errors.Wrap(err, "Error deleting message")
 				}
-				time.Sleep(minSleep * time.Millisecond)
+				if isSystemMessage(msg.Type) {
+					pages.attempted[msg.ID] = true
+				}
+				pause = c.sleep(c.deleteDelay(minSleep) + pages.extraDelay)
 			}
 			// Increment regardless of whether it's a dry run
-			c.deletedCount++
+			deleted := atomic.AddInt64(&c.deletedCount, 1)
+			c.rate.add(time.Now(), deleted)
+			c.progress.add(&msg)
+			pages.deleted++
+			pages.lastDeleted, _ = strconv.ParseInt(msg.ID, 10, 64)
+
+			err = c.record(&msg)
+			if err != nil {
+				return err
+			}
+
+			// The message is gone and accounted for, so an interrupted delay can stop the run now
+			if pause != nil {
+				return pause
+			}
+			err = c.endBatch(deleted)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// logDeletion logs the message about to be deleted, only at info level for every nth deletion
+func (c *Client) logDeletion(msg *Message) {
+	next := c.DeletedCount() + 1
+	if c.logEvery > 1 && next%int64(c.logEvery) != 0 {
+		log.Debugf("Deleting message %v from channel %v", msg.ID, msg.ChannelID)
+		return
+	}
+
+	if c.logEvery > 1 {
+		log.Infof("Deleting message %v from channel %v, %v deleted so far", msg.ID, msg.ChannelID, next-1)
+		return
+	}
+	log.Infof("Deleting message %v from channel %v", msg.ID, msg.ChannelID)
+}
+
+func (c *Client) DeletedCount() int64 {
+	return atomic.LoadInt64(&c.deletedCount)
+}
+
+func (c *Client) RequestCount() int64 {
+	return atomic.LoadInt64(&c.requestCount)
+}
+
+// active reports whether we've posted in a channel or guild too recently for it to be
+// considered dormant, in which case it's left alone
+func (c *Client) active(search string, channel *Channel, me *Me) (bool, error) {
+	if c.dormantAge == 0 {
+		return false, nil
+	}
+
+	newest, err := c.newestMessage(search, channel, me)
+	if err != nil || newest == nil {
+		return false, err
+	}
+
+	snowflake, err := strconv.ParseInt(newest.ID, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+
+	sent := time.Unix(0, fromSnowflake(snowflake)*int64(time.Millisecond))
+	return time.Since(sent) < c.dormantAge, nil
+}
+
+// includesPhase reports whether the run should include the given phase
+func (c *Client) includesPhase(phase string) bool {
+	if phaseOrder[phase] < phaseOrder[c.startPhase] {
+		log.Infof("Skipping %v, starting from %v", phase, c.startPhase)
+		return false
+	}
+	return true
+}
+
+func (c *Client) targetGuild(guild string) bool {
+	if len(c.guilds) == 0 {
+		return true
+	}
+	for _, target := range c.guilds {
+		if guild == target {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Client) skipChannel(channel string) bool {
+	if c.systemChannels.has(channel) {
+		return true
+	}
 	for _, skip := range c.skipChannels {
 		if channel == skip {
 			return true
@@ -232,8 +1134,81 @@ func (c *Client) skipChannel(channel string) bool {
 	return false
 }
 
+func (c *Client) inBounds(id string) bool {
+	if c.minID == 0 && c.maxID == 0 {
+		return true
+	}
+
+	snowflake, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		// We can't tell, so leave it to the server's judgement
+		return true
+	}
+
+	if c.minID > 0 && snowflake < c.minID {
+		return false
+	}
+	if c.maxID > 0 && snowflake > c.maxID {
+		return false
+	}
+	return true
+}
+
+func (c *Client) setHeaders(req *http.Request) {
+	// There's no token yet while logging in
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	req.Header.Set("X-Super-Properties", c.spoof.SuperProps)
+	req.Header.Set("User-Agent", c.spoof.UserAgent)
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+}
+
+// request is for listing things, where being forbidden just means there's nothing to list
+// Searching and deleting use strictRequest, since a 403 there means something was skipped
 func (c *Client) request(method string, endpoint string, reqData interface{}, resData interface{}) error {
-	url := api + endpoint
+	err := c.strictRequest(method, endpoint, reqData, resData)
+	// We're forbidden from doing plenty of things that don't matter to us, such as
+	// searching channels we can no longer read, so don't treat them as failures
+	if hasStatus(err, http.StatusForbidden) {
+		return nil
+	}
+	return err
+}
+
+// strictRequest is like request, but surfaces a 403 as a StatusError rather than ignoring it
+// Server errors are usually brief outages, so they're retried with a backoff before giving up
+func (c *Client) strictRequest(method string, endpoint string, reqData interface{}, resData interface{}) error {
+	backoff := serverBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := c.send(method, endpoint, reqData, resData)
+		// A delete that went through before its response was lost to a server error
+		// isn't there for the retry to find, but it was still deleted by us
+		if method == "DELETE" && attempt > 1 && hasStatus(err, http.StatusNotFound) {
+			log.Debugf("%v %v had already gone through before the retry", method, endpoint)
+			return nil
+		}
+		if !serverError(err) || attempt > c.serverRetries {
+			return err
+		}
+
+		log.Warnf("%v for %v %v, retrying in %v (%v/%v)", err, method, endpoint, backoff, attempt, c.serverRetries)
+		err = c.sleep(backoff)
+		if err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// send makes a single request, waiting out rate limits and network outages
+func (c *Client) send(method string, endpoint string, reqData interface{}, resData interface{}) error {
+	url := c.baseURL + endpoint
 	log.Debugf("%v %v", method, url)
 
 	buffer := new(bytes.Buffer)
@@ -243,21 +1218,38 @@ func (c *Client) request(method string, endpoint string, reqData interface{}, re
 			return errors.Wrap(err, "Error encoding request data")
 		}
 	}
-	req, err := http.NewRequest(method, url, buffer)
+	req, err := http.NewRequestWithContext(c.context(), method, url, buffer)
 	if err != nil {
 		return errors.Wrap(err, "Error building request")
 	}
-	req.Header.Set("Authorization", c.token)
-	req.Header.Set("X-Super-Properties", c.spoof.SuperProps)
-	req.Header.Set("User-Agent", c.spoof.UserAgent)
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 
+	err = c.global.wait(c.context())
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
 	res, err := c.httpClient.Do(req)
 	if err != nil {
+		if c.interrupted() != nil {
+			return ErrorInterrupted
+		}
+		if c.networkWait > 0 && c.waitForNetwork() {
+			return c.send(method, endpoint, reqData, resData)
+		}
 		return errors.Wrap(err, "Error sending request")
 	}
 
-	c.requestCount++
+	// Only time the round trip itself, rate limit sleeps are logged separately
+	if log.GetLevel() >= log.DebugLevel {
+		elapsed := time.Since(start)
+		c.timings.add(elapsed)
+		log.Debugf("%v %v took %v", method, url, elapsed)
+	}
+
+	atomic.AddInt64(&c.requestCount, 1)
+	c.routes.add(method, endpoint)
 
 	defer func() {
 		err := res.Body.Close()
@@ -270,7 +1262,7 @@ func (c *Client) request(method string, endpoint string, reqData interface{}, re
 
 	switch status := res.StatusCode; {
 	case status >= http.StatusInternalServerError:
-		return fmt.Errorf("Bad status code %v", http.StatusText(res.StatusCode))
+		return &StatusError{StatusCode: res.StatusCode}
 	case status == http.StatusAccepted:
 		// retry_after is an integer in milliseconds
 		err := c.wait(res, 1)
@@ -278,7 +1270,7 @@ func (c *Client) request(method string, endpoint string, reqData interface{}, re
 			return err
 		}
 		// Try again once we've waited for the period that the server has asked us to.
-		return c.request(method, endpoint, reqData, resData)
+		return c.send(method, endpoint, reqData, resData)
 	case status == http.StatusTooManyRequests:
 		// retry_after is a float in seconds
 		err := c.wait(res, 1000)
@@ -286,19 +1278,26 @@ func (c *Client) request(method string, endpoint string, reqData interface{}, re
 			return err
 		}
 		// Try again once we've waited for the period that the server has asked us to.
-		return c.request(method, endpoint, reqData, resData)
+		return c.send(method, endpoint, reqData, resData)
 	case status == http.StatusForbidden:
-		break
+		return newStatusError(res)
+	case status == http.StatusNotFound:
+		return newStatusError(res)
 	case status == http.StatusUnauthorized:
-		return fmt.Errorf("Bad status code %v, log out and log back in to Discord or verify your token is correct", http.StatusText(res.StatusCode))
+		// The token worked earlier in the run, so it's been revoked rather than mistyped
+		if atomic.LoadInt32(&c.authorized) == 1 {
+			return c.invalidated()
+		}
+		return ErrorUnauthorized
 	case status == http.StatusBadRequest:
-		return fmt.Errorf("Bad status code %v", http.StatusText(res.StatusCode))
+		return newStatusError(res)
 	case status == http.StatusNoContent:
-		break
+		atomic.StoreInt32(&c.authorized, 1)
 	case status == http.StatusOK:
+		atomic.StoreInt32(&c.authorized, 1)
 		err := json.NewDecoder(res.Body).Decode(resData)
 		if err != nil {
-			return errors.Wrap(err, "Error decoding response")
+			return &DecodeError{err}
 		}
 	default:
 		return fmt.Errorf("Status code %v is unhandled", http.StatusText(res.StatusCode))
@@ -307,6 +1306,98 @@ func (c *Client) request(method string, endpoint string, reqData interface{}, re
 	return nil
 }
 
+// StatusError is returned when the server responds with a status code we can't recover from
+// Code and Message are Discord's own explanation, when the body included one
+type StatusError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+// newStatusError reads Discord's error envelope from the body, if there is one
+func newStatusError(res *http.Response) *StatusError {
+	var envelope struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	// The status code alone is enough to go on, so a missing or odd body isn't an error
+	json.NewDecoder(io.LimitReader(res.Body, 64*1024)).Decode(&envelope)
+
+	return &StatusError{res.StatusCode, envelope.Code, envelope.Message}
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("Bad status code %v: %v (%v)", http.StatusText(e.StatusCode), e.Message, e.Code)
+	}
+	return fmt.Sprintf("Bad status code %v", http.StatusText(e.StatusCode))
+}
+
+// DecodeError is returned when a response arrived but couldn't be decoded, usually because
+// the body was cut short, as opposed to a network error where nothing arrived at all
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Error decoding response: %v", e.Err)
+}
+
+// ErrorUnauthorized is returned when the token is rejected from the start of a run
+var ErrorUnauthorized = errors.New("Bad status code Unauthorized, log out and log back in to Discord or verify your token is correct")
+
+// TokenInvalidatedError is returned when the token stops working part way through a run,
+// usually because the password was changed or the account logged out everywhere
+type TokenInvalidatedError struct {
+	Deleted int64
+}
+
+func (e *TokenInvalidatedError) Error() string {
+	return fmt.Sprintf("Token became invalid during the run, %v messages deleted before failure", e.Deleted)
+}
+
+// invalidated saves the checkpoint so the run can be resumed with a fresh token
+func (c *Client) invalidated() error {
+	if c.checkpoint != nil && !c.dryRun {
+		err := c.checkpoint.flush()
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+
+	return &TokenInvalidatedError{atomic.LoadInt64(&c.deletedCount)}
+}
+
+func tokenInvalidated(err error) bool {
+	_, ok := errors.Cause(err).(*TokenInvalidatedError)
+	return ok
+}
+
+func serverError(err error) bool {
+	statusErr, ok := errors.Cause(err).(*StatusError)
+	return ok && statusErr.StatusCode >= http.StatusInternalServerError
+}
+
+// hasStatus reports whether the error is a StatusError with one of the given status codes
+func hasStatus(err error, codes ...int) bool {
+	statusErr, ok := errors.Cause(err).(*StatusError)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if statusErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// refused reports whether the server rejected deleting a system message, rather than the
+// request failing in transit. Discord answers with either 400 or 403 for types it won't delete.
+func refused(err error) bool {
+	return hasStatus(err, http.StatusBadRequest, http.StatusForbidden)
+}
+
 func (c *Client) wait(res *http.Response, mult int) error {
 	data := new(ServerWait)
 	err := json.NewDecoder(res.Body).Decode(data)
@@ -316,37 +1407,154 @@ func (c *Client) wait(res *http.Response, mult int) error {
 
 	// Multiply retry_after by the mult passed in
 	millis := time.Duration(data.RetryAfter*float32(mult)) * time.Millisecond
+
+	// The route's reset headers are more precise when the server sends them, but they
+	// only describe this route's bucket, not a global limit or a 202 while indexing
+	if res.StatusCode == http.StatusTooManyRequests && !data.Global {
+		if reset, ok := resetWait(res.Header, time.Now()); ok {
+			millis = reset
+		}
+	}
 	log.Infof("Server asked us to sleep for %v", millis)
-	time.Sleep(millis)
 
-	return nil
+	// Don't blindly trust the server, a bogus retry_after could leave us asleep for hours
+	if millis > c.maxRetryAfter {
+		log.Warnf("Requested sleep exceeds the maximum of %v, sleeping for %v instead", c.maxRetryAfter, c.maxRetryAfter)
+		millis = c.maxRetryAfter
+	}
+
+	if data.Global {
+		// Every request is held back, including the retry of this one
+		c.global.pause(millis)
+		return nil
+	}
+
+	// Only this route is limited, so only this caller needs to back off
+	return c.sleep(millis)
+}
+
+// resetWait works out how long to wait from X-RateLimit-Reset-After, the seconds until
+// the limit resets, or failing that X-RateLimit-Reset, the unix time in seconds at which
+// it does. Our clock may not agree with the server's, so the wait until the reset time is
+// measured from the server's Date header where there is one rather than from now.
+func resetWait(header http.Header, now time.Time) (time.Duration, bool) {
+	after, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64)
+	if err == nil && after > 0 {
+		return time.Duration(after * float64(time.Second)), true
+	}
+
+	reset, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset"), 64)
+	if err != nil || reset <= 0 {
+		return 0, false
+	}
+
+	serverNow := now
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		serverNow = date
+	}
+
+	resetAt := time.Unix(0, int64(reset*float64(time.Second)))
+	wait := resetAt.Sub(serverNow)
+	if wait <= 0 {
+		return 0, false
+	}
+
+	return wait, true
 }
 
 // https://discord.com/developers/docs/resources/channel#message-object-message-types
 const (
-	UserMessage = 0
-	UserReply   = 19
+	UserMessage          = 0
+	CallMessage          = 3
+	ChannelPinnedMessage = 6
+	GuildMemberJoin      = 7
+	ThreadCreated        = 18
+	UserReply            = 19
+	ChatInputCommand     = 20
+	ContextMenuCommand   = 23
+)
+
+// Message types we attempt to delete
+// Recipient changes, channel name/icon changes and boosts are never deletable, so they're skipped
+// Calls are only deletable some of the time, if the server refuses we skip past them
+var deletableTypes = map[int]bool{
+	// Our own messages and replies
+	UserMessage: true,
+	UserReply:   true,
+	// Calls we started, which Discord lets the caller delete in some DMs and groups
+	CallMessage: true,
+	// The notice for a message we pinned, which is sent as ours
+	ChannelPinnedMessage: true,
+	// The welcome notice for our own join, deletable where we can manage messages
+	GuildMemberJoin: true,
+	// The notice for a thread we started, which is sent as ours
+	ThreadCreated: true,
+	// Slash and context menu commands we ran, which are shown as sent by us
+	ChatInputCommand:   true,
+	ContextMenuCommand: true,
+}
+
+func isSystemMessage(msgType int) bool {
+	return msgType != UserMessage && msgType != UserReply
+}
+
+// Phases of a partial deletion, in the order they run
+const (
+	PhaseChannels      = "channels"
+	PhaseRelationships = "relationships"
+	PhaseGuilds        = "guilds"
 )
 
+var phaseOrder = map[string]int{
+	PhaseChannels:      0,
+	PhaseRelationships: 1,
+	PhaseGuilds:        2,
+}
+
 // https://discord.com/developers/docs/resources/channel#channel-object-channel-types
 const (
-	DirectChannel = 1
+	GuildText          = 0
+	DirectChannel      = 1
+	GuildVoice         = 2
+	GroupDirect        = 3
+	GuildCategory      = 4
+	GuildAnnouncement  = 5
+	AnnouncementThread = 10
+	PublicThread       = 11
+	PrivateThread      = 12
+	GuildStage         = 13
+	GuildDirectory     = 14
+	GuildForum         = 15
+	GuildMedia         = 16
 )
 
 type Me struct {
-	ID string `json:"id"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Discriminator string `json:"discriminator"`
+	GlobalName    string `json:"global_name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Flags         int64  `json:"flags"`
+	PublicFlags   int64  `json:"public_flags"`
 }
 
 type Channel struct {
-	Type       int         `json:"type"`
-	ID         string      `json:"id"`
-	Recipients []Recipient `json:"recipients"`
-	Name       string      `json:"name,omitempty"`
+	Type           int             `json:"type"`
+	ID             string          `json:"id"`
+	ParentID       string          `json:"parent_id,omitempty"`
+	Recipients     []Recipient     `json:"recipients"`
+	Name           string          `json:"name,omitempty"`
+	ThreadMetadata *ThreadMetadata `json:"thread_metadata,omitempty"`
+	// Slowmode in seconds, only present on guild channels
+	RateLimitPerUser int `json:"rate_limit_per_user,omitempty"`
+	// The most recent message, for comparing against how far we've read
+	LastMessageID string `json:"last_message_id,omitempty"`
 }
 
 type Recipient struct {
-	Username string `json:"username"`
-	ID       string `json:"id"`
+	Username      string `json:"username"`
+	Discriminator string `json:"discriminator"`
+	ID            string `json:"id"`
 }
 
 type Relationship struct {
@@ -356,17 +1564,109 @@ type Relationship struct {
 }
 
 type Message struct {
-	ID        string `json:"id"`
-	Hit       bool   `json:"hit,omitempty"`
+	ID        string      `json:"id"`
+	Hit       bool        `json:"hit,omitempty"`
+	ChannelID string      `json:"channel_id"`
+	Type      int         `json:"type"`
+	Content   string      `json:"content"`
+	Author    Recipient   `json:"author"`
+	Mentions  []Recipient `json:"mentions"`
+	// Only set on messages sent through a webhook
+	WebhookID string `json:"webhook_id,omitempty"`
+	// Set on replies, forwards and crossposts, pointing at the original message
+	MessageReference *MessageReference `json:"message_reference,omitempty"`
+	// Only present on replies, and null if the original has been deleted
+	ReferencedMessage *Message `json:"referenced_message,omitempty"`
+	// Null unless the message has been edited since it was sent
+	EditedTimestamp *time.Time `json:"edited_timestamp,omitempty"`
+	// The message exactly as the server sent it, for diagnostics
+	raw json.RawMessage
+}
+
+type MessageReference struct {
+	MessageID string `json:"message_id"`
 	ChannelID string `json:"channel_id"`
-	Type      int    `json:"type"`
+	GuildID   string `json:"guild_id,omitempty"`
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	// Alias the type so that we don't recurse back into this method
+	type message Message
+	err := json.Unmarshal(data, (*message)(m))
+	if err != nil {
+		return err
+	}
+
+	m.raw = append(json.RawMessage(nil), data...)
+	return nil
 }
 
 type Messages struct {
+	AnalyticsID     string      `json:"analytics_id"`
 	TotalResults    int         `json:"total_results"`
 	ContextMessages [][]Message `json:"messages"`
+	// Threads that any of the messages were found in
+	Threads []Channel `json:"threads,omitempty"`
+	// Set when the messages were in a shape we don't recognise, and so couldn't be read
+	unrecognised bool
+}
+
+// UnmarshalJSON accepts both the original search response, where each hit is grouped
+// with the messages around it, and newer ones which return a flat list of hits
+// A shape we don't recognise is warned about and treated as an empty page rather than failing
+func (m *Messages) UnmarshalJSON(data []byte) error {
+	// Alias the type so that we don't recurse back into this method
+	type messages Messages
+	envelope := struct {
+		*messages
+		ContextMessages json.RawMessage `json:"messages"`
+	}{messages: (*messages)(m)}
+
+	err := json.Unmarshal(data, &envelope)
+	if err != nil {
+		return err
+	}
+
+	m.ContextMessages = nil
+	if len(envelope.ContextMessages) == 0 || string(envelope.ContextMessages) == "null" {
+		return nil
+	}
+
+	var grouped [][]Message
+	if json.Unmarshal(envelope.ContextMessages, &grouped) == nil {
+		m.ContextMessages = grouped
+	} else {
+		var flat []Message
+		if json.Unmarshal(envelope.ContextMessages, &flat) != nil {
+			log.Warnf("Unexpected shape of search results, treating as empty: %s", envelope.ContextMessages)
+			m.unrecognised = true
+			return nil
+		}
+		for _, msg := range flat {
+			m.ContextMessages = append(m.ContextMessages, []Message{msg})
+		}
+	}
+
+	// Without any context messages there's nothing to mark which ones are hits,
+	// since every message returned is one. A group of several unmarked messages
+	// could hold anyone's context though, so those are left alone.
+	for _, ctx := range m.ContextMessages {
+		for _, msg := range ctx {
+			if msg.Hit {
+				return nil
+			}
+		}
+	}
+	for _, ctx := range m.ContextMessages {
+		if len(ctx) == 1 {
+			ctx[0].Hit = true
+		}
+	}
+
+	return nil
 }
 
 type ServerWait struct {
 	RetryAfter float32 `json:"retry_after"`
+	Global     bool    `json:"global"`
 }
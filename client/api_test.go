@@ -0,0 +1,733 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// searchServer serves channel and guild searches over a fixed number of messages for each
+// If forbidFrom is positive, searches from that offset onwards are forbidden
+func searchServer(counts map[string]int, forbidFrom int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /channels/<id>/messages/search or /guilds/<id>/messages/search
+		parts := strings.Split(r.URL.Path, "/")
+		channel := parts[2]
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		if forbidFrom > 0 && offset >= forbidFrom {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		results := Messages{TotalResults: counts[channel]}
+		for i := offset; i < counts[channel] && i < offset+limit; i++ {
+			results.ContextMessages = append(results.ContextMessages, []Message{{
+				ID:        fmt.Sprintf("%v%04d", channel, i),
+				Hit:       true,
+				ChannelID: channel,
+				Type:      UserMessage,
+				Author:    Recipient{ID: "me"},
+			}})
+		}
+
+		json.NewEncoder(w).Encode(results)
+	}))
+}
+
+func TestTruncatedSearchRetried(t *testing.T) {
+	settleDelay = 20 * time.Millisecond
+	defer func() { settleDelay = 2 * time.Second }()
+
+	var requests int
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		times = append(times, time.Now())
+		if requests <= 2 {
+			fmt.Fprint(w, `{"total_results":1,"messages":[[{"id":"100`)
+			return
+		}
+		fmt.Fprint(w, `{"total_results":1,"messages":[[{"id":"1000","hit":true,"channel_id":"1"}]]}`)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+
+	seek := 0
+	results, err := c.ChannelMessages(&Channel{ID: "1"}, &Me{ID: "me"}, &seek, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 1, results.TotalResults)
+	// Each retry waits a moment rather than following straight on
+	for i := 1; i < len(times); i++ {
+		assert.True(t, times[i].Sub(times[i-1]) >= settleDelay)
+	}
+
+	// A body that never decodes gives up after the retries, and isn't mistaken for a network error
+	requests = -100
+	_, err = c.ChannelMessages(&Channel{ID: "1"}, &Me{ID: "me"}, &seek, 0)
+	_, ok := errors.Cause(err).(*DecodeError)
+	assert.True(t, ok)
+	assert.Equal(t, -100+1+searchDecodeRetries, requests)
+}
+
+func TestConcurrentDryRun(t *testing.T) {
+	counts := map[string]int{"1": 30, "2": 55, "3": 7}
+	server := searchServer(counts, 0)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	var wg sync.WaitGroup
+	for id := range counts {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: id})
+			assert.Nil(t, err)
+		}(id)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(30+55+7), c.DeletedCount())
+}
+
+func TestGuildLeftMidRun(t *testing.T) {
+	server := searchServer(map[string]int{"1": 60}, messageLimit)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	err := c.DeleteFromGuild(&Me{ID: "me"}, &Channel{ID: "1", Name: "left"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(messageLimit), c.DeletedCount())
+	assert.Equal(t, []string{"left"}, c.inaccessibleGuilds)
+}
+
+func TestMessageKeepsRawJSON(t *testing.T) {
+	data := `{"id":"1","type":7,"flags":4}`
+
+	var msg Message
+	err := json.Unmarshal([]byte(data), &msg)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, msg.Type)
+	assert.Equal(t, data, string(msg.raw))
+}
+
+func TestTokenInvalidatedMidRun(t *testing.T) {
+	search := searchServer(map[string]int{"1": 60}, 0)
+	defer search.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.True(t, tokenInvalidated(err))
+	assert.EqualError(t, errors.Cause(err), fmt.Sprintf("Token became invalid during the run, %v messages deleted before failure", messageLimit))
+}
+
+func TestGuildEmptyFirstPage(t *testing.T) {
+	search := searchServer(map[string]int{"1": 30}, 0)
+	defer search.Close()
+
+	// The index is still warming up for the first couple of searches
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 2 {
+			json.NewEncoder(w).Encode(Messages{})
+			return
+		}
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	settleDelay = time.Millisecond
+	defer func() { settleDelay = 2 * time.Second }()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetEmptyPageRetries(2)
+
+	err := c.DeleteFromGuild(&Me{ID: "me"}, &Channel{ID: "1", Name: "warming"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), c.DeletedCount())
+}
+
+func TestSearchResponseShapes(t *testing.T) {
+	shapes := map[string]string{
+		"grouped with context": `{"total_results":1,"messages":[[{"id":"1"},{"id":"2","hit":true},{"id":"3"}]]}`,
+		"flat":                 `{"total_results":1,"messages":[{"id":"2"}],"threads":[{"id":"9","type":11}]}`,
+		"grouped without hits": `{"total_results":1,"messages":[[{"id":"2"}]],"members":[]}`,
+	}
+
+	for name, data := range shapes {
+		var results Messages
+		err := json.Unmarshal([]byte(data), &results)
+		assert.Nil(t, err, name)
+		assert.Equal(t, 1, hits(&results), name)
+		assert.Equal(t, int64(2), oldestHit(&results), name)
+	}
+
+	var results Messages
+	err := json.Unmarshal([]byte(`{"total_results":5,"messages":{"unexpected":true}}`), &results)
+	assert.Nil(t, err)
+	assert.Empty(t, results.ContextMessages)
+	assert.Equal(t, 5, results.TotalResults)
+}
+
+func TestUnrecognisedShapeNotFinished(t *testing.T) {
+	settleDelay = time.Millisecond
+	defer func() { settleDelay = 2 * time.Second }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_results":5,"messages":{"unexpected":true}}`)
+	}))
+	defer server.Close()
+
+	checkpoint, err := LoadCheckpoint(t.TempDir() + "/checkpoint.json")
+	assert.Nil(t, err)
+
+	me := &Me{ID: "me", Username: "someone"}
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetMarkerDir(t.TempDir())
+	c.SetCheckpoint(checkpoint)
+
+	// Nothing could be read, so the channel is searched again next time and the account isn't clean
+	assert.Nil(t, c.DeleteFromChannel(me, &Channel{ID: "1"}))
+	assert.False(t, checkpoint.completed("1"))
+	assert.True(t, atomic.LoadInt64(&c.unreadPages) > 0)
+	assert.Nil(t, c.markClean(me))
+	assert.False(t, c.alreadyClean(me))
+}
+
+func TestUnflaggedContextNotDeleted(t *testing.T) {
+	// Grouped with context but nothing marked as the hit, so there's no telling which is ours
+	data := `{"total_results":1,"messages":[[{"id":"1","author":{"id":"other"}},{"id":"2","author":{"id":"me"}},{"id":"3","author":{"id":"other"}}]]}`
+
+	var results Messages
+	assert.Nil(t, json.Unmarshal([]byte(data), &results))
+	assert.Equal(t, 0, hits(&results))
+
+	// Nor is a hit by someone else deleted if the server marks one
+	results.ContextMessages = append(results.ContextMessages, []Message{{ID: "4", Hit: true, Type: UserMessage, Author: Recipient{ID: "other"}}})
+
+	seek := 0
+	pages := newPageTracker()
+	pages.author = "me"
+	c := New("token")
+	c.SetDryRun(true)
+	assert.Nil(t, c.DeleteMessages(&results, &seek, pages))
+	assert.Equal(t, int64(0), c.DeletedCount())
+	assert.Equal(t, 1, seek)
+}
+
+func TestVerifyRelationships(t *testing.T) {
+	c := New("token")
+	c.relationOutcomes = map[string]string{"1": "already open", "2": "reopened", "3": "already open"}
+
+	c.verifyRelationships([]Relationship{
+		{ID: "1"}, {ID: "2"}, {ID: "3"},
+		{ID: "4", Recipient: Recipient{Username: "forgotten"}},
+	})
+
+	assert.Equal(t, []string{"forgotten"}, c.unhandledRelations)
+	assert.Equal(t, "1 reopened, 2 already open", c.relationSummary())
+}
+
+func TestRelationshipOutcomes(t *testing.T) {
+	search := searchServer(map[string]int{"13": 2}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me/relationships":
+			fmt.Fprint(w, `[
+				{"id":"1","type":1,"user":{"id":"1","username":"open"}},
+				{"id":"2","type":3,"user":{"id":"2","username":"request"}},
+				{"id":"3","type":1,"user":{"id":"3","username":"closed"}},
+				{"id":"4","type":2,"user":{"id":"4","username":"broken"}}
+			]`)
+		case r.Method == "POST" && r.URL.Path == "/users/@me/channels":
+			var body struct {
+				Recipients []string `json:"recipients"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Recipients[0] == "4" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"1%v","type":1,"recipients":[{"id":"%v"}]}`, body.Recipients[0], body.Recipients[0])
+		default:
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	channels := []Channel{{ID: "11", Type: DirectChannel, Recipients: []Recipient{{ID: "1"}}}}
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetBestEffort(true)
+	c.SetServerRetries(0)
+	assert.Nil(t, c.SetRelationshipTypes([]int{RelationshipFriend, RelationshipBlocked}))
+
+	// Every relationship ends up with an outcome, including the one that failed
+	err := c.DeleteFromRelationships(&Me{ID: "me"}, channels)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"1": "already open",
+		"2": "skipped by type",
+		"3": "reopened",
+		"4": "failed to resolve",
+	}, c.relationOutcomes)
+	assert.Empty(t, c.unhandledRelations)
+	assert.Equal(t, []string{"broken"}, c.failedRelations)
+	assert.Equal(t, int64(2), c.DeletedCount())
+
+	// Without --best-effort the failure ends the run instead
+	c = New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetServerRetries(0)
+	err = c.DeleteFromRelationships(&Me{ID: "me"}, channels)
+	assert.True(t, hasStatus(err, http.StatusInternalServerError))
+	assert.Equal(t, "reopened", c.relationOutcomes["3"])
+	assert.Empty(t, c.relationOutcomes["4"])
+}
+
+func TestRelationshipsWithEmptyDM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"1","type":1,"user":{"id":"1","username":"friend"}}]`)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetNoReopenDMs(true)
+
+	// A DM whose recipient list came back empty, alongside one that's fine
+	channels := []Channel{
+		{ID: "10", Type: DirectChannel},
+		{ID: "11", Type: DirectChannel, Recipients: []Recipient{{ID: "1"}}},
+	}
+	err := c.DeleteFromRelationships(&Me{ID: "me"}, channels)
+	assert.Nil(t, err)
+	assert.Equal(t, "already open", c.relationOutcomes["1"])
+}
+
+func TestRelationshipsUnavailable(t *testing.T) {
+	status := http.StatusForbidden
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	me := &Me{ID: "1", Username: "someone"}
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetMarkerDir(t.TempDir())
+	c.SetServerRetries(0)
+
+	// Forbidden moves on, but the closed DMs were never checked so the account isn't clean
+	assert.Nil(t, c.DeleteFromRelationships(me, nil))
+	assert.Nil(t, c.markClean(me))
+	assert.False(t, c.alreadyClean(me))
+
+	// Anything else is a failure rather than being skipped
+	status = http.StatusInternalServerError
+	err := c.DeleteFromRelationships(me, nil)
+	assert.True(t, hasStatus(err, http.StatusInternalServerError))
+}
+
+func TestRelationshipTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"1","type":1,"user":{"id":"1","username":"friend"}},{"id":"2","type":2,"user":{"id":"2","username":"blocked"}}]`)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetNoReopenDMs(true)
+	assert.Nil(t, c.SetRelationshipTypes([]int{RelationshipBlocked}))
+
+	err := c.DeleteFromRelationships(&Me{ID: "me"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "skipped by type", c.relationOutcomes["1"])
+	assert.Equal(t, "left closed", c.relationOutcomes["2"])
+	assert.False(t, c.fullRun())
+
+	assert.Equal(t, ErrorInvalidRelationshipType, errors.Cause(c.SetRelationshipTypes([]int{5})))
+}
+
+func TestNoRelationDedup(t *testing.T) {
+	search := searchServer(map[string]int{"11": 3}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me/relationships":
+			fmt.Fprint(w, `[{"id":"1","type":2,"user":{"id":"1","username":"blocked"}}]`)
+		case r.Method == "POST" && r.URL.Path == "/users/@me/channels":
+			fmt.Fprint(w, `{"id":"11","type":1,"recipients":[{"id":"1","username":"blocked"}]}`)
+		default:
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// The channels phase was skipped, so the open DM hasn't been deleted from yet
+	channels := []Channel{{ID: "11", Type: DirectChannel, Recipients: []Recipient{{ID: "1"}}}}
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetNoReopenDMs(true)
+	c.SetNoRelationDedup(true)
+	err := c.DeleteFromRelationships(&Me{ID: "me"}, channels)
+	assert.Nil(t, err)
+	assert.Equal(t, "resolved while open", c.relationOutcomes["1"])
+	assert.Equal(t, int64(3), c.DeletedCount())
+
+	// The skip list still applies to the channel the relationship resolves to
+	c = New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetNoRelationDedup(true)
+	c.SetSkipChannels([]string{"11"})
+	err = c.DeleteFromRelationships(&Me{ID: "me"}, channels)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), c.DeletedCount())
+}
+
+func TestOverlappingChannelVisitedOnce(t *testing.T) {
+	search := searchServer(map[string]int{"11": 3}, 0)
+	defer search.Close()
+
+	searches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me/relationships":
+			fmt.Fprint(w, `[{"id":"1","type":1,"user":{"id":"1","username":"friend"}}]`)
+		case r.Method == "POST" && r.URL.Path == "/users/@me/channels":
+			fmt.Fprint(w, `{"id":"11","type":1,"recipients":[{"id":"1","username":"friend"}]}`)
+		default:
+			searches++
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// The open DM came back without its recipient, so the relationship isn't matched to it
+	channels := []Channel{{ID: "11", Type: DirectChannel}}
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	me := &Me{ID: "me"}
+
+	assert.Nil(t, c.DeleteFromChannel(me, &channels[0]))
+	visits := searches
+
+	assert.Nil(t, c.DeleteFromRelationships(me, channels))
+	assert.Equal(t, "already processed", c.relationOutcomes["1"])
+	assert.Equal(t, visits, searches)
+	assert.Equal(t, int64(3), c.DeletedCount())
+}
+
+func TestScanVoiceTextChat(t *testing.T) {
+	search := searchServer(map[string]int{"2": 5, "3": 4, "4": 1, "5": 1}, 0)
+	defer search.Close()
+
+	var searched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/guilds/1/channels" {
+			fmt.Fprintf(w, `[{"id":"2","type":%v,"name":"voice"},{"id":"3","type":%v,"name":"stage"},{"id":"4","type":%v,"name":"category"},{"id":"5","type":%v,"name":"directory"}]`,
+				GuildVoice, GuildStage, GuildCategory, GuildDirectory)
+			return
+		}
+		searched = append(searched, strings.Split(r.URL.Path, "/")[2])
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	err := c.scanGuildChannels(&Me{ID: "me"}, &Channel{ID: "1", Name: "guild"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5+4), c.DeletedCount())
+	assert.NotContains(t, searched, "4")
+	assert.NotContains(t, searched, "5")
+}
+
+func TestMaxPerChannel(t *testing.T) {
+	counts := map[string]int{"1": 30, "2": 5}
+	server := searchServer(counts, 0)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetMaxPerChannel(10)
+
+	for _, id := range []string{"1", "2"} {
+		err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: id})
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, int64(10+5), c.DeletedCount())
+}
+
+func TestLimit(t *testing.T) {
+	server := searchServer(map[string]int{"1": 30, "2": 30}, 0)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetLimit(40)
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	err = c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "2"})
+	assert.Equal(t, ErrorLimitReached, err)
+	assert.Equal(t, int64(40), c.DeletedCount())
+}
+
+func TestServerErrorRetried(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","username":"someone"}`)
+	}))
+	defer server.Close()
+
+	serverBackoff = time.Millisecond
+	defer func() { serverBackoff = time.Second }()
+
+	c := New("token")
+	c.baseURL = server.URL
+
+	me, err := c.Me()
+	assert.Nil(t, err)
+	assert.Equal(t, "someone", me.Username)
+	assert.Equal(t, 3, requests)
+
+	// An outage that outlasts the retries is given up on
+	requests = 0
+	c.SetServerRetries(1)
+	_, err = c.Me()
+	assert.True(t, serverError(err))
+	assert.Equal(t, 2, requests)
+}
+
+func TestDeleteRetryAlreadyDeleted(t *testing.T) {
+	deletes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deletes++
+		if deletes%2 == 1 {
+			// The first attempt goes through, but the response is lost to a server error
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Unknown Message","code":10008}`)
+	}))
+	defer server.Close()
+
+	serverBackoff = time.Millisecond
+	defer func() { serverBackoff = time.Second }()
+
+	var export bytes.Buffer
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetExport(&export)
+
+	// The retry's 404 means the first attempt deleted it, so it's counted and recorded
+	seek := 0
+	page := &Messages{ContextMessages: [][]Message{{{ID: "1", ChannelID: "1", Hit: true, Type: UserMessage}}}}
+	err := c.DeleteMessages(page, &seek, newPageTracker())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, deletes)
+	assert.Equal(t, 0, seek)
+	assert.Equal(t, int64(1), c.DeletedCount())
+	assert.Contains(t, export.String(), `"id":"1"`)
+
+	// The same goes for messages from a data package
+	err = c.DeleteFromPackage([]Message{{ID: "2", ChannelID: "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, deletes)
+	assert.Equal(t, int64(2), c.DeletedCount())
+
+	// Without a retry, the message was deleted by someone else and isn't ours to count
+	deletes = 1
+	err = c.DeleteMessages(&Messages{ContextMessages: [][]Message{{{ID: "3", ChannelID: "1", Hit: true, Type: UserMessage}}}}, &seek, newPageTracker())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), c.DeletedCount())
+}
+
+func TestMissingChannelSkipped(t *testing.T) {
+	search := searchServer(map[string]int{"2": 30}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/channels/1/") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Unknown Channel","code":10003}`)
+			return
+		}
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	me := &Me{ID: "me"}
+	assert.Nil(t, c.DeleteFromChannel(me, &Channel{ID: "1"}))
+	assert.Nil(t, c.DeleteFromChannel(me, &Channel{ID: "2"}))
+	assert.Equal(t, int64(30), c.DeletedCount())
+	assert.Equal(t, []string{"1"}, c.missingChannels.list())
+}
+
+func TestLogEvery(t *testing.T) {
+	server := searchServer(map[string]int{"1": 25}, 0)
+	defer server.Close()
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetLogEvery(10)
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25), c.DeletedCount())
+	assert.Equal(t, 2, strings.Count(out.String(), "Deleting message"))
+	assert.Contains(t, out.String(), "19 deleted so far")
+}
+
+func TestNothingAuthoredSkipped(t *testing.T) {
+	search := searchServer(map[string]int{"1": 0}, 0)
+	defer search.Close()
+
+	searches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	c := New("token")
+	c.baseURL = server.URL
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, searches)
+	assert.Contains(t, out.String(), "Channel 1: no messages, skipping")
+	assert.NotContains(t, out.String(), "No more messages")
+}
+
+func TestBatches(t *testing.T) {
+	server := searchServer(map[string]int{"1": 25}, 0)
+	defer server.Close()
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetBatch(10, time.Hour)
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(out.String(), "Finished a batch of 10 messages"))
+	assert.Contains(t, out.String(), "20 deleted so far")
+}
+
+func TestCooldownsInterrupted(t *testing.T) {
+	search := searchServer(map[string]int{"1": 5}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		search.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetContext(ctx)
+	c.SetBatch(1, time.Hour)
+
+	// The delay after the first deletion is cut short, but the deletion still counts
+	start := time.Now()
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Equal(t, ErrorInterrupted, errors.Cause(err))
+	assert.Equal(t, int64(1), c.DeletedCount())
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// As is the cooldown between channels
+	c = New("token")
+	c.SetContext(ctx)
+	c.SetChannelCooldown(time.Hour)
+	c.passCount = 1
+	assert.Equal(t, ErrorInterrupted, c.startPass())
+}
@@ -1,21 +1,229 @@
 package client
 
 import (
-	"errors"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var (
-	ErrorInvalidDuration = errors.New("Failed to parse duration")
+	ErrorInvalidDuration  = errors.New("Failed to parse duration")
+	ErrorInvalidStrategy  = errors.New("Unknown pagination strategy")
+	ErrorInvalidCAFile    = errors.New("No certificates found in CA file")
+	ErrorInvalidPhase     = errors.New("Unknown phase, expected channels, relationships or guilds")
+	ErrorInvalidBaseURL   = errors.New("API base URL must be an absolute http or https URL")
+	ErrorInvalidWebhooks  = errors.New("Unknown webhook filter, expected only or exclude")
+	ErrorInvalidReplies   = errors.New("Unknown reply filter, expected only or exclude")
+	ErrorInvalidForbidden = errors.New("Unknown handling for forbidden requests, expected skip or fail")
+	ErrorInvalidHeader    = errors.New("Headers must look like 'Name: value'")
+	ErrorInvalidRetryCap  = errors.New("Maximum retry after must be more than zero, or we'd retry rate limited requests straight away")
 )
 
 const day = time.Hour * 24
 
+// SetBaseURL points the client at a different API, e.g. a local mock, rather than Discord
+// The URL should include the API version, like https://discord.com/api/v8
+func (c *Client) SetBaseURL(base string) error {
+	parsed, err := url.Parse(base)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ErrorInvalidBaseURL
+	}
+
+	c.baseURL = strings.TrimSuffix(base, "/")
+	return nil
+}
+
+// SetHeaders adds extra headers, each like "Name: value", to every request
+// They're applied over the usual headers, except for Authorization which is never replaced
+func (c *Client) SetHeaders(headers []string) error {
+	c.headers = make(http.Header)
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return errors.Wrapf(ErrorInvalidHeader, "Invalid header '%v'", header)
+		}
+
+		if http.CanonicalHeaderKey(name) == "Authorization" {
+			log.Warn("Ignoring the Authorization header, the token is always sent as it is")
+			continue
+		}
+		c.headers.Add(name, strings.TrimSpace(parts[1]))
+	}
+
+	return nil
+}
+
 func (c *Client) SetDryRun(dryRun bool) {
 	c.dryRun = dryRun
 }
 
+// SetBestEffort controls whether failing to resolve a relationship to a channel
+// aborts the run, or is logged and collected into the end of run summary
+func (c *Client) SetBestEffort(bestEffort bool) {
+	c.bestEffort = bestEffort
+}
+
+// SetMaxRetryAfter caps how long we're willing to sleep when the server asks us to wait
+func (c *Client) SetMaxRetryAfter(maxRetryAfter time.Duration) error {
+	if maxRetryAfter <= 0 {
+		return ErrorInvalidRetryCap
+	}
+	c.maxRetryAfter = maxRetryAfter
+	return nil
+}
+
+// SetPerChannelGuildScan enables searching each guild channel individually once the
+// guild wide search is finished
+func (c *Client) SetPerChannelGuildScan(perChannelGuildScan bool) {
+	c.perChannelGuildScan = perChannelGuildScan
+}
+
+func (c *Client) SetSkipRelationships(skipRelationships bool) {
+	c.skipRelationships = skipRelationships
+}
+
+// SetNetworkWait sets how long to pause the run for while waiting for a dropped
+// connection to return, zero disables waiting altogether
+func (c *Client) SetNetworkWait(networkWait time.Duration) {
+	c.networkWait = networkWait
+}
+
+func (c *Client) SetStrategy(strategy string) error {
+	if strategy != StrategyOffset && strategy != StrategyMaxID {
+		return ErrorInvalidStrategy
+	}
+	c.strategy = strategy
+	return nil
+}
+
+// SetDormantOnly skips channels where we've posted within the given number of days,
+// written as e.g. 30d
+func (c *Client) SetDormantOnly(dormant string) error {
+	days, err := parseDays(dormant)
+	if err != nil {
+		return err
+	}
+	c.dormantAge = time.Duration(days) * day
+	return nil
+}
+
+func parseDays(value string) (uint, error) {
+	days, err := strconv.ParseUint(strings.TrimSuffix(value, "d"), 10, 32)
+	if err != nil {
+		return 0, ErrorInvalidDuration
+	}
+	return uint(days), nil
+}
+
+// SetLogMessageTypes logs every undeletable message in full, along with a table of
+// how often each message type was seen, to help decide which types to delete
+func (c *Client) SetLogMessageTypes(logMessageTypes bool) {
+	c.types.enabled = logMessageTypes
+}
+
+// SetTLSConfig trusts the certificates in caFile on top of the system roots, for networks
+// which intercept TLS. Setting insecure skips certificate verification entirely.
+func (c *Client) SetTLSConfig(caFile string, insecure bool) error {
+	config := &tls.Config{
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			// Not available on every platform, in which case only trust the given CA
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return ErrorInvalidCAFile
+		}
+		config.RootCAs = pool
+	}
+
+	c.httpClient.Transport.(*http.Transport).TLSClientConfig = config
+
+	return nil
+}
+
+// SetEmptyPageRetries retries an empty first page of guild search results n times
+// before concluding that there's nothing to delete in the guild
+func (c *Client) SetEmptyPageRetries(n int) {
+	c.emptyPageRetries = n
+}
+
+// SetServerRetries sets how many times a request is retried after a server error
+func (c *Client) SetServerRetries(n int) {
+	c.serverRetries = n
+}
+
+// SetLimit stops the run after n messages have been deleted, zero means no limit
+// With a checkpoint, the next run picks up where this one stopped
+func (c *Client) SetLimit(n int64) {
+	c.maxDeletions = n
+}
+
+// SetLogEvery only logs every nth deletion, the rest are still logged when verbose
+func (c *Client) SetLogEvery(n int) {
+	c.logEvery = n
+}
+
+// SetMaxPerChannel stops deleting from each channel or guild after n messages, zero means no limit
+// With a checkpoint, the next run picks up each channel where this one stopped
+func (c *Client) SetMaxPerChannel(n int) {
+	c.maxPerChannel = n
+}
+
+// SetBatch deletes size messages at the usual pace, then sleeps for cooldown before the
+// next batch, zero size disables batching
+func (c *Client) SetBatch(size int, cooldown time.Duration) {
+	c.batchSize = size
+	c.batchCooldown = cooldown
+}
+
+// SetChannelCooldown sleeps between channels, to spread requests out on very active accounts
+func (c *Client) SetChannelCooldown(cooldown time.Duration) {
+	c.channelCooldown = cooldown
+}
+
+// SetNoReopenDMs stops closed DMs from being reopened to delete from them
+// Reopening a DM makes it appear in the DM list again, on our side only
+func (c *Client) SetNoReopenDMs(noReopenDMs bool) {
+	c.noReopenDMs = noReopenDMs
+}
+
+// SetNoRelationDedup resolves every relationship, even those whose DM is already open
+// The open DM is found again, and the skip list still applies to it
+func (c *Client) SetNoRelationDedup(noRelationDedup bool) {
+	c.noRelationDedup = noRelationDedup
+}
+
+// SetStartPhase skips every phase of a partial deletion before the given one
+func (c *Client) SetStartPhase(phase string) error {
+	if _, ok := phaseOrder[phase]; !ok {
+		return ErrorInvalidPhase
+	}
+	c.startPhase = phase
+	return nil
+}
+
+// SetGuilds limits the guilds phase to the given guild IDs
+func (c *Client) SetGuilds(guilds []string) {
+	c.guilds = guilds
+}
+
 func (c *Client) SetSkipChannels(skipChannels []string) {
 	c.skipChannels = skipChannels
 }
@@ -24,8 +232,7 @@ func (c *Client) SetMinAge(minAge uint) error {
 	t := time.Now().Add(-time.Duration(minAge) * day)
 	millis := t.UnixNano() / int64(time.Millisecond)
 
-	c.maxID = toSnowflake(millis)
-	log.Debugf("Message maximum ID must be %v", c.maxID)
+	c.SetMaxID(toSnowflake(millis))
 
 	return nil
 }
@@ -34,8 +241,23 @@ func (c *Client) SetMaxAge(maxAge uint) error {
 	t := time.Now().Add(-time.Duration(maxAge) * day)
 	millis := t.UnixNano() / int64(time.Millisecond)
 
-	c.minID = toSnowflake(millis)
-	log.Debugf("Message minimum ID must be %v", c.minID)
+	c.SetMinID(toSnowflake(millis))
 
 	return nil
 }
+
+// SetMinID only ever narrows the search, so the strictest bound wins
+// when combined with an age filter
+func (c *Client) SetMinID(minID int64) {
+	if minID > c.minID {
+		c.minID = minID
+	}
+	log.Debugf("Message minimum ID must be %v", c.minID)
+}
+
+func (c *Client) SetMaxID(maxID int64) {
+	if c.maxID == 0 || (maxID > 0 && maxID < c.maxID) {
+		c.maxID = maxID
+	}
+	log.Debugf("Message maximum ID must be %v", c.maxID)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseDays(t *testing.T) {
+	days, err := parseDays("30d")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(30), days)
+
+	days, err = parseDays("7")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(7), days)
+}
+
+func TestParseInvalidDays(t *testing.T) {
+	_, err := parseDays("a week")
+	assert.Equal(t, ErrorInvalidDuration, err)
+}
+
+func TestSetBaseURL(t *testing.T) {
+	c := New("token")
+
+	assert.Nil(t, c.SetBaseURL("http://localhost:8080/api/v8/"))
+	assert.Equal(t, "http://localhost:8080/api/v8", c.baseURL)
+
+	assert.Equal(t, ErrorInvalidBaseURL, c.SetBaseURL("localhost:8080"))
+	assert.Equal(t, ErrorInvalidBaseURL, c.SetBaseURL("ftp://example.com"))
+	assert.Equal(t, ErrorInvalidBaseURL, c.SetBaseURL("/api/v8"))
+}
+
+func TestSetMaxRetryAfter(t *testing.T) {
+	c := New("token")
+
+	assert.Nil(t, c.SetMaxRetryAfter(time.Minute))
+	assert.Equal(t, time.Minute, c.maxRetryAfter)
+
+	// No cap at all would retry rate limited requests without waiting
+	assert.Equal(t, ErrorInvalidRetryCap, c.SetMaxRetryAfter(0))
+	assert.Equal(t, ErrorInvalidRetryCap, c.SetMaxRetryAfter(-time.Second))
+	assert.Equal(t, time.Minute, c.maxRetryAfter)
+}
+
+func TestSetHeaders(t *testing.T) {
+	c := New("token")
+	assert.Nil(t, c.SetHeaders([]string{"X-Debug: 1", "X-Trace:a:b", "authorization: stolen"}))
+
+	req, _ := http.NewRequest("GET", "https://example.com", nil)
+	c.setHeaders(req)
+	assert.Equal(t, "1", req.Header.Get("X-Debug"))
+	assert.Equal(t, "a:b", req.Header.Get("X-Trace"))
+	assert.Equal(t, "token", req.Header.Get("Authorization"))
+
+	for _, header := range []string{"X-Debug", ": value", "X Debug: 1"} {
+		err := c.SetHeaders([]string{header})
+		assert.Equal(t, ErrorInvalidHeader, errors.Cause(err), header)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// rename moves the finished temporary file into place, tests swap it to simulate a crash
+var rename = os.Rename
+
+// WriteFileAtomic writes data to a temporary file beside path and renames it into place,
+// so a crash part way through leaves either the old file or the new one, never half of each
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := ioutil.TempFile(dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Only does anything if we fail before the rename
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), perm)
+	if err != nil {
+		return err
+	}
+
+	return rename(tmp.Name(), path)
+}
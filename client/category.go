@@ -0,0 +1,62 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DeleteFromCategory deletes messages only from the channels grouped under a category,
+// searching each of them individually. The category may be in any guild we're in.
+func (c *Client) DeleteFromCategory(category string) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return errors.Wrap(err, "Error fetching guilds")
+	}
+
+	for _, guild := range guilds {
+		if !c.targetGuild(guild.ID) {
+			continue
+		}
+
+		channels, err := c.GuildChannels(&guild)
+		if err != nil {
+			return errors.Wrap(err, "Error fetching guild channels")
+		}
+		if !hasCategory(channels, category) {
+			continue
+		}
+
+		log.Infof("Deleting from channels in category %v of guild '%v'", category, guild.Name)
+		c.findSystemChannels(&guild)
+		for _, channel := range channels {
+			if channel.ParentID != category {
+				continue
+			}
+
+			err = c.deleteFromGuildChannel(me, &guild, &channel)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.logSummary()
+		return nil
+	}
+
+	return fmt.Errorf("No category with ID %v found in any guild", category)
+}
+
+func hasCategory(channels []Channel, category string) bool {
+	for _, channel := range channels {
+		if channel.ID == category && channel.Type == GuildCategory {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync/atomic"
+	"time"
+)
+
+// Flags on the account which Discord sets when it suspects automated use
+// Neither is documented, but both have been observed on flagged accounts
+const (
+	flagSpammer     = 1 << 20
+	flagQuarantined = 1 << 44
+)
+
+// How far deletions can be slowed down by when the account keeps being flagged
+const maxSlowdown = 16
+
+// SetCautious checks the account's flags before each channel, slowing deletions down
+// each time they show the account has been flagged
+func (c *Client) SetCautious(cautious bool) {
+	c.cautious = cautious
+}
+
+// deleteDelay is how long to wait between deletions
+func (c *Client) deleteDelay(base time.Duration) time.Duration {
+	slowdown := atomic.LoadInt32(&c.slowdown)
+	if slowdown < 1 {
+		slowdown = 1
+	}
+	return base * time.Duration(slowdown)
+}
+
+// checkFlags doubles the delay between deletions if the account has been flagged
+func (c *Client) checkFlags() {
+	if !c.cautious {
+		return
+	}
+
+	me, err := c.Me()
+	if err != nil {
+		log.Warnf("Couldn't check account flags: %v", err)
+		return
+	}
+
+	flags := me.Flags | me.PublicFlags
+	if flags&(flagSpammer|flagQuarantined) == 0 {
+		return
+	}
+
+	slowdown := atomic.LoadInt32(&c.slowdown)
+	if slowdown < 1 {
+		slowdown = 1
+	}
+	if slowdown >= maxSlowdown {
+		log.Warnf("Account is still flagged (flags %v), deletions are already slowed down %v times", flags, slowdown)
+		return
+	}
+
+	atomic.StoreInt32(&c.slowdown, slowdown*2)
+	log.Warnf("Account has been flagged by Discord (flags %v), slowing deletions down %v times", flags, slowdown*2)
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCautiousSlowdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"1","flags":%v}`, flagSpammer)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	assert.Equal(t, 200*time.Millisecond, c.deleteDelay(200*time.Millisecond))
+
+	// Nothing changes unless it's been asked for
+	c.checkFlags()
+	assert.Equal(t, 200*time.Millisecond, c.deleteDelay(200*time.Millisecond))
+
+	c.SetCautious(true)
+	c.checkFlags()
+	assert.Equal(t, 400*time.Millisecond, c.deleteDelay(200*time.Millisecond))
+
+	for i := 0; i < 10; i++ {
+		c.checkFlags()
+	}
+	assert.Equal(t, maxSlowdown*200*time.Millisecond, c.deleteDelay(200*time.Millisecond))
+}
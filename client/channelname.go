@@ -0,0 +1,64 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// GuildChannel is a channel along with the guild it belongs to
+type GuildChannel struct {
+	Guild   Channel
+	Channel Channel
+}
+
+// FindChannels finds the channels with the given name in every guild we're in,
+// ignoring case and any leading #
+func (c *Client) FindChannels(name string) ([]GuildChannel, error) {
+	name = strings.TrimPrefix(name, "#")
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching guilds")
+	}
+	sortChannels(guilds)
+
+	var found []GuildChannel
+	for _, guild := range guilds {
+		if !c.targetGuild(guild.ID) {
+			continue
+		}
+
+		channels, err := c.GuildChannels(&guild)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error fetching guild channels")
+		}
+		for _, channel := range channels {
+			if channel.Type != GuildCategory && strings.EqualFold(channel.Name, name) {
+				found = append(found, GuildChannel{guild, channel})
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// DeleteFromGuildChannels searches each of the given guild channels individually
+func (c *Client) DeleteFromGuildChannels(targets []GuildChannel) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		c.findSystemChannels(&target.Guild)
+
+		err = c.deleteFromGuildChannel(me, &target.Guild, &target.Channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logSummary()
+
+	return nil
+}
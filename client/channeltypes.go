@@ -0,0 +1,63 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var channelTypeNames = map[int]string{
+	GuildText:          "text",
+	DirectChannel:      "dm",
+	GuildVoice:         "voice",
+	GroupDirect:        "group dm",
+	GuildCategory:      "category",
+	GuildAnnouncement:  "announcement",
+	AnnouncementThread: "announcement thread",
+	PublicThread:       "public thread",
+	PrivateThread:      "private thread",
+	GuildStage:         "stage",
+	GuildDirectory:     "directory",
+	GuildForum:         "forum",
+	GuildMedia:         "media",
+}
+
+// ChannelTypeName describes a channel type, falling back to its number for types we don't know
+func ChannelTypeName(channelType int) string {
+	name, ok := channelTypeNames[channelType]
+	if !ok {
+		return fmt.Sprintf("unknown (%v)", channelType)
+	}
+	return name
+}
+
+// ChannelTypes counts the channels of each type across open DMs and every guild
+// Guilds whose channels can't be listed are skipped with a warning
+func (c *Client) ChannelTypes() (map[int]int, error) {
+	counts := make(map[int]int)
+
+	channels, err := c.Channels()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching channels")
+	}
+	for _, channel := range channels {
+		counts[channel.Type]++
+	}
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching guilds")
+	}
+	for _, guild := range guilds {
+		channels, err := c.GuildChannels(&guild)
+		if err != nil {
+			log.Warnf("Couldn't list channels in guild '%v': %v", guild.Name, err)
+			continue
+		}
+		for _, channel := range channels {
+			counts[channel.Type]++
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// ErrorAccountMismatch is returned when resuming a checkpoint written for another account
+var ErrorAccountMismatch = errors.New("Resuming with a different account, pass --force-account-mismatch to carry on anyway")
+
+// Checkpoint records progress through each channel (or guild) so that an interrupted
+// run can pick up where it left off. Each cursor is the oldest message processed in
+// that channel, which is fed back into the search as max_id when resuming.
+type Checkpoint struct {
+	// The account the checkpoint was written for, so it isn't resumed with another
+	UserID    string           `json:"user_id,omitempty"`
+	Cursors   map[string]int64 `json:"cursors"`
+	Completed map[string]bool  `json:"completed"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// LoadCheckpoint reads the checkpoint at path, starting afresh if it doesn't exist yet
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		Cursors:   make(map[string]int64),
+		Completed: make(map[string]bool),
+		path:      path,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading checkpoint")
+	}
+
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing checkpoint")
+	}
+
+	// Older or hand edited checkpoints may be missing either map
+	if cp.Cursors == nil {
+		cp.Cursors = make(map[string]int64)
+	}
+	if cp.Completed == nil {
+		cp.Completed = make(map[string]bool)
+	}
+
+	return cp, nil
+}
+
+func (cp *Checkpoint) cursor(id string) int64 {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.Cursors[id]
+}
+
+func (cp *Checkpoint) completed(id string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.Completed[id]
+}
+
+func (cp *Checkpoint) update(id string, cursor int64) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.Cursors[id] = cursor
+	return cp.save()
+}
+
+// complete marks a channel as finished, clearing its cursor since it's no longer needed
+func (cp *Checkpoint) complete(id string) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	delete(cp.Cursors, id)
+	cp.Completed[id] = true
+	return cp.save()
+}
+
+func (cp *Checkpoint) flush() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.save()
+}
+
+// save must be called with the lock held
+func (cp *Checkpoint) save() error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return errors.Wrap(err, "Error encoding checkpoint")
+	}
+
+	// Saved after every page, so it's the file most likely to be cut short by a crash
+	err = WriteFileAtomic(cp.path, data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Error writing checkpoint")
+	}
+
+	return nil
+}
+
+func (c *Client) SetCheckpoint(cp *Checkpoint) {
+	c.checkpoint = cp
+}
+
+// SetForceAccountMismatch resumes a checkpoint even if it was written for another account
+func (c *Client) SetForceAccountMismatch(force bool) {
+	c.forceAccount = force
+}
+
+// owner returns the account the checkpoint belongs to, claiming it for id if it has none
+func (cp *Checkpoint) owner(id string) string {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if cp.UserID == "" {
+		cp.UserID = id
+	}
+	return cp.UserID
+}
+
+func (cp *Checkpoint) reassign(id string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.UserID = id
+}
+
+// checkAccount makes sure a checkpoint being resumed was written for the account we're
+// logged in as, since its channels and cursors mean nothing to any other account
+func (c *Client) checkAccount(me *Me) error {
+	if c.checkpoint == nil {
+		return nil
+	}
+
+	owner := c.checkpoint.owner(me.ID)
+	if owner == me.ID {
+		return nil
+	}
+
+	if !c.forceAccount {
+		return errors.Wrapf(ErrorAccountMismatch, "Checkpoint was written for user %v, but the token is for %v (%v)", owner, me.ID, me.Username)
+	}
+
+	log.Warnf("Checkpoint was written for user %v, resuming it as %v (%v) anyway", owner, me.ID, me.Username)
+	c.checkpoint.reassign(me.ID)
+	return nil
+}
+
+// resumeFrom returns the cursor to resume a channel from, and whether it's already finished
+func (c *Client) resumeFrom(id string) (int64, bool) {
+	if c.checkpoint == nil {
+		return 0, false
+	}
+
+	cursor := c.checkpoint.cursor(id)
+	if cursor > 0 {
+		log.Infof("Resuming channel %v from message %v", id, cursor)
+	}
+
+	return cursor, c.checkpoint.completed(id)
+}
+
+// checkpointPage records that every hit on the page has been processed
+func (c *Client) checkpointPage(id string, results *Messages) error {
+	return c.checkpointAt(id, oldestHit(results))
+}
+
+// checkpointAt records that every message from cursor onwards has been processed
+// Dry runs don't delete anything, so they never move the checkpoint on
+func (c *Client) checkpointAt(id string, cursor int64) error {
+	if c.checkpoint == nil || c.dryRun || cursor == 0 {
+		return nil
+	}
+
+	return c.checkpoint.update(id, cursor)
+}
+
+func (c *Client) checkpointDone(id string) error {
+	if c.checkpoint == nil || c.dryRun {
+		return nil
+	}
+
+	return c.checkpoint.complete(id)
+}
+
+// checkpointFinished marks a channel done once its search runs out of pages, unless the
+// search still reports results we haven't seeked past, e.g. a page we couldn't read,
+// in which case the next run searches it again
+func (c *Client) checkpointFinished(id string, results *Messages, seek int) error {
+	if results.TotalResults > seek {
+		log.Warnf("Search still reports %v results for %v but returned none, leaving it unfinished", results.TotalResults, id)
+		return nil
+	}
+
+	return c.checkpointDone(id)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp, err := LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Nil(t, cp.update("1", 100))
+	assert.Nil(t, cp.update("2", 200))
+	assert.Nil(t, cp.complete("2"))
+
+	cp, err = LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), cp.cursor("1"))
+	assert.False(t, cp.completed("1"))
+	assert.Equal(t, int64(0), cp.cursor("2"))
+	assert.True(t, cp.completed("2"))
+}
+
+func TestCheckpointCrashMidWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+
+	cp, err := LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Nil(t, cp.update("1", 100))
+
+	// Dying after the new checkpoint is written out but before it's moved into place
+	// leaves a stray temporary file and the previous checkpoint untouched
+	rename = func(string, string) error { return errors.New("crashed") }
+	err = cp.update("1", 200)
+	rename = os.Rename
+	assert.NotNil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path+".tmp123", []byte(`{"channels":{"1":{"cur`), 0600))
+
+	cp, err = LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), cp.cursor("1"))
+
+	// The resumed run carries on saving as normal
+	assert.Nil(t, cp.update("1", 300))
+	cp, err = LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(300), cp.cursor("1"))
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tmp*"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{path + ".tmp123"}, matches)
+}
+
+func TestCheckpointAccount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp, err := LoadCheckpoint(path)
+	assert.Nil(t, err)
+
+	c := New("token")
+	c.SetCheckpoint(cp)
+	assert.Nil(t, c.checkAccount(&Me{ID: "1"}))
+	assert.Nil(t, cp.update("10", 100))
+
+	// A rotated token for the same account resumes as normal
+	cp, err = LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", cp.UserID)
+	c = New("rotated")
+	c.SetCheckpoint(cp)
+	assert.Nil(t, c.checkAccount(&Me{ID: "1"}))
+
+	err = c.checkAccount(&Me{ID: "2", Username: "other"})
+	assert.Equal(t, ErrorAccountMismatch, errors.Cause(err))
+
+	c.SetForceAccountMismatch(true)
+	assert.Nil(t, c.checkAccount(&Me{ID: "2", Username: "other"}))
+	assert.Equal(t, "2", cp.UserID)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+var (
+	// ErrorStopped is returned once a run has been asked to stop between pages
+	ErrorStopped = errors.New("Stopped on request")
+	// ErrorLimitReached is returned once a run has deleted as many messages as it's allowed to
+	ErrorLimitReached = errors.New("Reached the limit of messages to delete")
+)
+
+// Control lets a run be paused, resumed or stopped from outside the client, e.g.
+// from the keyboard. Requests only take effect between pages, once the current
+// page has been deleted and checkpointed.
+type Control struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	paused  bool
+	stopped bool
+}
+
+func NewControl() *Control {
+	ctl := &Control{}
+	ctl.cond = sync.NewCond(&ctl.mu)
+	return ctl
+}
+
+func (ctl *Control) Pause() {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	ctl.paused = true
+}
+
+func (ctl *Control) Resume() {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	ctl.paused = false
+	ctl.cond.Broadcast()
+}
+
+// Stop also releases a paused run, so that it can exit
+func (ctl *Control) Stop() {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	ctl.stopped = true
+	ctl.cond.Broadcast()
+}
+
+// Stopped reports whether the run has been asked to stop
+func (ctl *Control) Stopped() bool {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	return ctl.stopped
+}
+
+// wait blocks for as long as the run is paused, returning ErrorStopped if it should end
+func (ctl *Control) wait() error {
+	ctl.mu.Lock()
+	defer ctl.mu.Unlock()
+
+	if ctl.paused && !ctl.stopped {
+		log.Info("Paused")
+		for ctl.paused && !ctl.stopped {
+			ctl.cond.Wait()
+		}
+		if !ctl.stopped {
+			log.Info("Resumed")
+		}
+	}
+
+	if ctl.stopped {
+		return ErrorStopped
+	}
+	return nil
+}
+
+func (c *Client) SetControl(ctl *Control) {
+	c.control = ctl
+}
+
+// betweenPages gives the control a chance to pause or stop the run
+func (c *Client) betweenPages() error {
+	err := c.interrupted()
+	if err != nil {
+		return err
+	}
+
+	if c.control == nil {
+		return nil
+	}
+	return c.control.wait()
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestControlPauseResume(t *testing.T) {
+	ctl := NewControl()
+	ctl.Pause()
+
+	done := make(chan error)
+	go func() {
+		done <- ctl.wait()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("wait returned while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctl.Resume()
+	assert.Nil(t, <-done)
+}
+
+func TestControlStopWhilePaused(t *testing.T) {
+	ctl := NewControl()
+	ctl.Pause()
+
+	done := make(chan error)
+	go func() {
+		done <- ctl.wait()
+	}()
+
+	ctl.Stop()
+	assert.Equal(t, ErrorStopped, <-done)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// MessageCount is how many of our messages the search reports in an open DM or a guild
+type MessageCount struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// MessageCounts counts our messages in each open DM and guild with a single search
+// each, without deleting anything, sorted with the most messages first
+func (c *Client) MessageCounts() ([]MessageCount, error) {
+	me, err := c.Me()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching profile information")
+	}
+
+	var counts []MessageCount
+
+	channels, err := c.Channels()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching channels")
+	}
+	for _, channel := range channels {
+		count, err := c.estimate("channel_msgs", &channel, me)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error counting messages in channel")
+		}
+		counts = append(counts, MessageCount{JobChannel, channel.ID, dmName(&channel), count})
+	}
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching guilds")
+	}
+	for _, guild := range guilds {
+		count, err := c.estimate("guild_msgs", &guild, me)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error counting messages in guild")
+		}
+		counts = append(counts, MessageCount{JobGuild, guild.ID, guild.Name, count})
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+
+	return counts, nil
+}
+
+// MessageTotal adds up MessageCounts, for a headline figure of how many messages the
+// account has left
+func (c *Client) MessageTotal() (int, error) {
+	counts, err := c.MessageCounts()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count.Count
+	}
+
+	return total, nil
+}
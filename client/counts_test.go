@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMessageCounts(t *testing.T) {
+	search := searchServer(map[string]int{"1": 3, "2": 40, "3": 7}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"someone"}`)
+		case r.URL.Path == "/users/@me/channels":
+			fmt.Fprint(w, `[{"id":"1","type":1,"recipients":[{"id":"5","username":"friend","discriminator":"0"}]}]`)
+		case r.URL.Path == "/users/@me/guilds":
+			fmt.Fprint(w, `[{"id":"2","name":"busy"},{"id":"3","name":"quiet"}]`)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			assert.Equal(t, "1", r.URL.Query().Get("limit"))
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+
+	counts, err := c.MessageCounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []MessageCount{
+		{JobGuild, "2", "busy", 40},
+		{JobGuild, "3", "quiet", 7},
+		{JobChannel, "1", "friend", 3},
+	}, counts)
+	// The profile, the two lists, then a single search for each
+	assert.Equal(t, int64(6), c.RequestCount())
+
+	total, err := c.MessageTotal()
+	assert.Nil(t, err)
+	assert.Equal(t, 3+40+7, total)
+}
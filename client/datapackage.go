@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorNoPackageMessages is returned when a directory doesn't look like an extracted data package
+var ErrorNoPackageMessages = errors.New("No messages folder found, point at the extracted data package")
+
+// packageMessage is a single message from the messages.json file in a data package,
+// which replaced messages.csv in more recent packages
+type packageMessage struct {
+	ID       json.Number `json:"ID"`
+	Contents string      `json:"Contents"`
+}
+
+// ReadDataPackage reads every message listed in an extracted Discord data package
+// Each channel has its own folder under messages/, holding a channel.json along with
+// either a messages.json or, in older packages, a messages.csv
+func ReadDataPackage(dir string) ([]Message, error) {
+	folders, err := filepath.Glob(filepath.Join(dir, "messages", "*", "channel.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error searching data package")
+	}
+	if len(folders) == 0 {
+		return nil, ErrorNoPackageMessages
+	}
+
+	var messages []Message
+	for _, channelFile := range folders {
+		folder := filepath.Dir(channelFile)
+
+		data, err := ioutil.ReadFile(channelFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error reading channel from data package")
+		}
+		var channel Channel
+		err = json.Unmarshal(data, &channel)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing %v", channelFile)
+		}
+
+		found, err := readPackageChannel(folder)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].ChannelID = channel.ID
+		}
+		messages = append(messages, found...)
+	}
+
+	return messages, nil
+}
+
+func readPackageChannel(folder string) ([]Message, error) {
+	data, err := ioutil.ReadFile(filepath.Join(folder, "messages.json"))
+	if err == nil {
+		var found []packageMessage
+		err = json.Unmarshal(data, &found)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing messages in %v", folder)
+		}
+
+		messages := make([]Message, len(found))
+		for i, msg := range found {
+			messages[i] = Message{ID: msg.ID.String(), Content: msg.Contents}
+		}
+		return messages, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "Error reading messages from data package")
+	}
+
+	file, err := os.Open(filepath.Join(folder, "messages.csv"))
+	if os.IsNotExist(err) {
+		// Channels where we never said anything only have a channel.json
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading messages from data package")
+	}
+	defer file.Close()
+
+	return readPackageCSV(file)
+}
+
+// readPackageCSV parses the ID,Timestamp,Contents,Attachments columns of messages.csv
+func readPackageCSV(r io.Reader) ([]Message, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing messages.csv")
+	}
+
+	var messages []Message
+	for i, row := range rows {
+		// Skip the header
+		if i == 0 || len(row) == 0 {
+			continue
+		}
+
+		msg := Message{ID: row[0]}
+		if len(row) > 2 {
+			msg.Content = row[2]
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// DeleteFromPackage deletes exactly the given messages, without searching for them first
+// Messages which have already been deleted are skipped
+func (c *Client) DeleteFromPackage(messages []Message) error {
+	channels := make(map[string]bool)
+
+	for _, msg := range messages {
+		if c.overLimit() {
+			log.Infof("Reached the limit of %v messages, stopping", c.maxDeletions)
+			return ErrorLimitReached
+		}
+
+		if !channels[msg.ChannelID] {
+			channels[msg.ChannelID] = true
+			atomic.AddInt64(&c.passCount, 1)
+		}
+		atomic.AddInt64(&c.foundCount, 1)
+
+		if c.skipChannel(msg.ChannelID) || !c.inBounds(msg.ID) || !c.wanted(&msg) {
+			log.Debugf("Skipping message %v from channel %v", msg.ID, msg.ChannelID)
+			continue
+		}
+
+		err := c.planAllows(&msg)
+		if err != nil {
+			return err
+		}
+
+		err = c.archiveRaw(&msg)
+		if err != nil {
+			return err
+		}
+
+		c.logDeletion(&msg)
+		var pause error
+		if !c.dryRun {
+			err := c.DeleteMessage(&msg)
+			// Deleted elsewhere since the package was requested, so it isn't ours to count
+			if hasStatus(err, http.StatusNotFound) {
+				log.Debugf("Message %v has already been deleted", msg.ID)
+				continue
+			}
+			if c.deleteForbidden(&msg, err) {
+				continue
+			}
+			if err != nil {
+				return errors.Wrap(err, "Error deleting message")
+			}
+			pause = c.sleep(c.deleteDelay(minSleep))
+		}
+		deleted := atomic.AddInt64(&c.deletedCount, 1)
+		c.rate.add(time.Now(), deleted)
+		c.progress.add(&msg)
+
+		err = c.record(&msg)
+		if err != nil {
+			return err
+		}
+
+		if pause != nil {
+			return pause
+		}
+		err = c.endBatch(deleted)
+		if err != nil {
+			return err
+		}
+
+		err = c.betweenPages()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logSummary()
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePackageFile(t *testing.T, path string, data string) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(path, []byte(data), 0600)
+	assert.Nil(t, err)
+}
+
+func TestReadDataPackage(t *testing.T) {
+	dir := t.TempDir()
+
+	writePackageFile(t, filepath.Join(dir, "messages", "c111", "channel.json"), `{"id":"111","type":1}`)
+	writePackageFile(t, filepath.Join(dir, "messages", "c111", "messages.json"),
+		`[{"ID":1001,"Timestamp":"2021-01-01 00:00:00","Contents":"hello","Attachments":""}]`)
+
+	// Older packages use CSV, where contents can span lines
+	writePackageFile(t, filepath.Join(dir, "messages", "222", "channel.json"), `{"id":"222","type":0}`)
+	writePackageFile(t, filepath.Join(dir, "messages", "222", "messages.csv"),
+		"ID,Timestamp,Contents,Attachments\n2001,2020-01-01 00:00:00,\"two\nlines\",\n")
+
+	// Channels we never spoke in have no messages at all
+	writePackageFile(t, filepath.Join(dir, "messages", "c333", "channel.json"), `{"id":"333","type":0}`)
+
+	messages, err := ReadDataPackage(dir)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []Message{
+		{ID: "1001", ChannelID: "111", Content: "hello"},
+		{ID: "2001", ChannelID: "222", Content: "two\nlines"},
+	}, messages)
+}
+
+func TestReadDataPackageMissing(t *testing.T) {
+	_, err := ReadDataPackage(t.TempDir())
+	assert.Equal(t, ErrorNoPackageMessages, err)
+}
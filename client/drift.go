@@ -0,0 +1,121 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+var ErrorMissingFields = errors.New("Response is missing fields we rely on, Discord's API may have changed")
+
+// SetStrictFields fails a request whose response is missing fields we rely on, rather
+// than warning about it and carrying on with whatever was decoded
+func (c *Client) SetStrictFields(strictFields bool) {
+	c.strictFields = strictFields
+}
+
+// fieldWarnings remembers which missing fields have been warned about, so that API drift
+// is reported once rather than for every message on every page
+type fieldWarnings struct {
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+func newFieldWarnings() *fieldWarnings {
+	return &fieldWarnings{
+		warned: make(map[string]bool),
+	}
+}
+
+// first reports whether this is the first time the field has gone missing
+func (f *fieldWarnings) first(field string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.warned[field] {
+		return false
+	}
+	f.warned[field] = true
+	return true
+}
+
+// expectFields checks a decoded response for empty fields we rely on. Unknown fields
+// are always ignored, but a field Discord has renamed or dropped decodes as empty
+// without any error, so it's only noticed here.
+func (c *Client) expectFields(kind string, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if c.strictFields {
+		return errors.Wrapf(ErrorMissingFields, "%v without %v", kind, strings.Join(missing, ", "))
+	}
+
+	for _, field := range missing {
+		if c.fieldWarnings.first(kind + "." + field) {
+			log.Warnf("Discord returned a %v without %v, its API may have changed", kind, field)
+		}
+	}
+	return nil
+}
+
+func (c *Client) expectMe(me *Me) error {
+	if me.ID == "" {
+		return c.expectFields("profile", []string{"id"})
+	}
+	return nil
+}
+
+func (c *Client) expectChannels(kind string, channels []Channel) error {
+	for _, channel := range channels {
+		if channel.ID == "" {
+			return c.expectFields(kind, []string{"id"})
+		}
+	}
+	return nil
+}
+
+func (c *Client) expectRelationships(relationships []Relationship) error {
+	for _, relation := range relationships {
+		var missing []string
+		if relation.ID == "" {
+			missing = append(missing, "id")
+		}
+		if relation.Recipient.ID == "" {
+			missing = append(missing, "user.id")
+		}
+
+		err := c.expectFields("relationship", missing)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectMessages checks the hits on a search page, context messages are never deleted
+// so whatever they're missing doesn't matter
+func (c *Client) expectMessages(results *Messages) error {
+	for _, ctx := range results.ContextMessages {
+		for _, msg := range ctx {
+			if !msg.Hit {
+				continue
+			}
+
+			var missing []string
+			if msg.ID == "" {
+				missing = append(missing, "id")
+			}
+			if msg.ChannelID == "" {
+				missing = append(missing, "channel_id")
+			}
+
+			err := c.expectFields("message", missing)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
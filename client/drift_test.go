@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMissingFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// channel_id has been renamed, so it decodes as empty
+		fmt.Fprint(w, `{"total_results":2,"messages":[[{"id":"1000","hit":true,"channel":"1","author":{"id":"me"}}],[{"id":"1001","hit":true,"channel":"1","author":{"id":"me"}}]]}`)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+
+	seek := 0
+	results, err := c.ChannelMessages(&Channel{ID: "1"}, &Me{ID: "me"}, &seek, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, results.TotalResults)
+	assert.True(t, c.fieldWarnings.warned["message.channel_id"])
+	assert.False(t, c.fieldWarnings.first("message.channel_id"))
+
+	c.SetStrictFields(true)
+	_, err = c.ChannelMessages(&Channel{ID: "1"}, &Me{ID: "me"}, &seek, 0)
+	assert.Equal(t, ErrorMissingFields, errors.Cause(err))
+	assert.EqualError(t, err, "message without channel_id: "+ErrorMissingFields.Error())
+}
+
+func TestExpectRelationships(t *testing.T) {
+	c := New("token")
+	c.SetStrictFields(true)
+
+	assert.Nil(t, c.expectRelationships([]Relationship{{ID: "1", Recipient: Recipient{ID: "1"}}}))
+
+	err := c.expectRelationships([]Relationship{{ID: "1"}})
+	assert.EqualError(t, err, "relationship without user.id: "+ErrorMissingFields.Error())
+}
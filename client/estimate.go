@@ -0,0 +1,64 @@
+package client
+
+import (
+	"time"
+)
+
+// Rough costs on top of our own delays, used to estimate how long a run will take
+// Once a run gets going, Discord's rate limits hold deletions to around one a second,
+// and each page of search results takes about a second to fetch
+const (
+	deleteRateLimit = time.Second
+	searchPageCost  = time.Second
+)
+
+// RunEstimate is roughly how much a run will delete and how long it will take
+type RunEstimate struct {
+	Messages int
+	Channels int
+	Duration time.Duration
+}
+
+// Estimate searches everywhere a run would without deleting anything, then works out how
+// long deleting what it found would take with the configured delays and limits
+// Closed DMs can't be searched without reopening them, so they aren't included.
+func (c *Client) Estimate() (*RunEstimate, error) {
+	job, err := c.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	est := &RunEstimate{}
+	for _, entry := range job.Entries {
+		if entry.Estimate > 0 {
+			est.Messages += entry.Estimate
+			est.Channels++
+		}
+	}
+	if c.maxDeletions > 0 && int64(est.Messages) > c.maxDeletions {
+		est.Messages = int(c.maxDeletions)
+	}
+	est.Duration = c.estimateDuration(est.Messages, est.Channels)
+
+	return est, nil
+}
+
+func (c *Client) estimateDuration(messages int, channels int) time.Duration {
+	perMessage := c.deleteDelay(minSleep)
+	if perMessage < deleteRateLimit {
+		perMessage = deleteRateLimit
+	}
+
+	// Every channel needs at least one page, even if it only turns out to be empty
+	pages := (messages+messageLimit-1)/messageLimit + channels
+
+	total := time.Duration(messages)*perMessage + time.Duration(pages)*searchPageCost
+	if c.batchSize > 0 {
+		total += time.Duration(messages/c.batchSize) * c.batchCooldown
+	}
+	if channels > 1 {
+		total += time.Duration(channels-1) * c.channelCooldown
+	}
+
+	return total
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	c := New("token")
+	assert.Equal(t, 100*time.Second+6*time.Second, c.estimateDuration(100, 2))
+
+	c.SetBatch(50, time.Minute)
+	c.SetChannelCooldown(10 * time.Second)
+	assert.Equal(t, 100*time.Second+6*time.Second+2*time.Minute+10*time.Second, c.estimateDuration(100, 2))
+
+	// A slowed down account waits longer than the rate limit between deletions
+	c = New("token")
+	c.slowdown = 8
+	assert.Equal(t, 100*1600*time.Millisecond+5*time.Second, c.estimateDuration(100, 1))
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Record is a single deleted message, written to the export as a line of JSON
+// Dry runs write the messages they would have deleted in the same format
+type Record struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// Comparison is the result of comparing a predicted set of deletions against an actual one
+type Comparison struct {
+	Matched    []string
+	Missed     []string
+	Unexpected []string
+}
+
+// exporter serialises writes to the export, which can come from several channels at once
+type exporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func (c *Client) SetExport(w io.Writer) {
+	c.export = &exporter{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (c *Client) record(msg *Message) error {
+	c.recordPlan(msg)
+
+	err := c.recordTimestamp(msg)
+	if err != nil {
+		return err
+	}
+
+	if c.export == nil {
+		return nil
+	}
+
+	c.export.mu.Lock()
+	defer c.export.mu.Unlock()
+
+	err = c.export.encoder.Encode(Record{msg.ID, msg.ChannelID})
+	if err != nil {
+		return errors.Wrap(err, "Error writing export")
+	}
+
+	return nil
+}
+
+// timestamps writes when each deleted message was sent as a row of CSV
+type timestamps struct {
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+// SetTimestamps writes the ID, channel and time sent of every deleted message to w as
+// CSV, including in dry runs, for looking back at when you were most active
+// The header is left out when adding to rows an earlier run wrote
+func (c *Client) SetTimestamps(w io.Writer, header bool) error {
+	c.timestamps = &timestamps{w: csv.NewWriter(w)}
+	if !header {
+		return nil
+	}
+
+	c.timestamps.w.Write([]string{"message_id", "channel_id", "timestamp"})
+	c.timestamps.w.Flush()
+	return errors.Wrap(c.timestamps.w.Error(), "Error writing timestamps")
+}
+
+// sentAt decodes the time a message was sent from its ID
+func sentAt(id string) (time.Time, error) {
+	snowflake, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Error parsing message ID")
+	}
+
+	return time.Unix(0, fromSnowflake(snowflake)*int64(time.Millisecond)).UTC(), nil
+}
+
+func (c *Client) recordTimestamp(msg *Message) error {
+	if c.timestamps == nil {
+		return nil
+	}
+
+	sent, err := sentAt(msg.ID)
+	if err != nil {
+		return err
+	}
+
+	c.timestamps.mu.Lock()
+	defer c.timestamps.mu.Unlock()
+
+	// Flushed as we go, so an interrupted run still leaves every row it got to
+	c.timestamps.w.Write([]string{msg.ID, msg.ChannelID, sent.Format(time.RFC3339Nano)})
+	c.timestamps.w.Flush()
+	return errors.Wrap(c.timestamps.w.Error(), "Error writing timestamps")
+}
+
+// archiver writes each message in full, exactly as the search returned it
+type archiver struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetArchive writes every message to w as a line of JSON before it's deleted,
+// including in dry runs
+func (c *Client) SetArchive(w io.Writer) {
+	c.archive = &archiver{w: w}
+}
+
+func (c *Client) archiveRaw(msg *Message) error {
+	if c.archive == nil {
+		return nil
+	}
+
+	// Messages that didn't come from a search, e.g. from a data package, only have what we parsed
+	raw := msg.raw
+	if raw == nil {
+		var err error
+		raw, err = json.Marshal(msg)
+		if err != nil {
+			return errors.Wrap(err, "Error encoding message for archive")
+		}
+	}
+
+	// One message per line, however the server formatted it
+	var line bytes.Buffer
+	err := json.Compact(&line, raw)
+	if err != nil {
+		return errors.Wrap(err, "Error encoding message for archive")
+	}
+	line.WriteByte('\n')
+
+	c.archive.mu.Lock()
+	defer c.archive.mu.Unlock()
+
+	_, err = c.archive.w.Write(line.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "Error writing archive")
+	}
+
+	return nil
+}
+
+// ReadExport reads the records from an export, keyed by message ID
+func ReadExport(r io.Reader) (map[string]Record, error) {
+	records := make(map[string]Record)
+	decoder := json.NewDecoder(r)
+
+	for {
+		var rec Record
+		err := decoder.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Error reading export")
+		}
+		records[rec.ID] = rec
+	}
+
+	return records, nil
+}
+
+// Compare reports which predicted deletions happened, which didn't, and which
+// deletions happened without being predicted
+func Compare(predicted map[string]Record, actual map[string]Record) Comparison {
+	var cmp Comparison
+
+	for id := range predicted {
+		if _, ok := actual[id]; ok {
+			cmp.Matched = append(cmp.Matched, id)
+		} else {
+			cmp.Missed = append(cmp.Missed, id)
+		}
+	}
+
+	for id := range actual {
+		if _, ok := predicted[id]; !ok {
+			cmp.Unexpected = append(cmp.Unexpected, id)
+		}
+	}
+
+	sort.Strings(cmp.Matched)
+	sort.Strings(cmp.Missed)
+	sort.Strings(cmp.Unexpected)
+
+	return cmp
+}
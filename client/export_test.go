@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadExport(t *testing.T) {
+	data := `{"id":"1","channel_id":"10"}
+{"id":"2","channel_id":"20"}
+`
+	records, err := ReadExport(strings.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]Record{
+		"1": {"1", "10"},
+		"2": {"2", "20"},
+	}, records)
+}
+
+func TestReadExportInvalid(t *testing.T) {
+	_, err := ReadExport(strings.NewReader(`{"id":`))
+	assert.NotNil(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	predicted := map[string]Record{"1": {}, "2": {}, "3": {}}
+	actual := map[string]Record{"2": {}, "3": {}, "4": {}}
+
+	cmp := Compare(predicted, actual)
+	assert.Equal(t, []string{"2", "3"}, cmp.Matched)
+	assert.Equal(t, []string{"1"}, cmp.Missed)
+	assert.Equal(t, []string{"4"}, cmp.Unexpected)
+}
+
+func TestArchiveRaw(t *testing.T) {
+	var msg Message
+	err := json.Unmarshal([]byte("{\n  \"id\": \"1\",\n  \"attachments\": [{\"url\": \"a\"}]\n}"), &msg)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	c := New("token")
+	c.SetArchive(&buf)
+
+	err = c.archiveRaw(&msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":\"1\",\"attachments\":[{\"url\":\"a\"}]}\n", buf.String())
+}
+
+func TestTimestamps(t *testing.T) {
+	sent, err := sentAt("175928847299117063")
+	assert.Nil(t, err)
+	assert.Equal(t, "2016-04-30T11:18:25.796Z", sent.Format(time.RFC3339Nano))
+
+	server := searchServer(map[string]int{"1": 2}, 0)
+	defer server.Close()
+
+	var out bytes.Buffer
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	assert.Nil(t, c.SetTimestamps(&out, true))
+
+	err = c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "message_id,channel_id,timestamp\n10000,1,2015-01-01T00:00:00Z\n10001,1,2015-01-01T00:00:00Z\n", out.String())
+
+	// A later scheduled run adds its rows without repeating the header
+	out.Reset()
+	c = New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	assert.Nil(t, c.SetTimestamps(&out, false))
+	assert.Nil(t, c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"}))
+	assert.Equal(t, "10000,1,2015-01-01T00:00:00Z\n10001,1,2015-01-01T00:00:00Z\n", out.String())
+}
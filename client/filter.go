@@ -0,0 +1,223 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Links are anything Discord would turn into a clickable link
+var link = regexp.MustCompile(`(?i)https?://\S+`)
+
+// User mentions look like <@id>, or <@!id> when the user has a nickname
+var userMention = regexp.MustCompile(`<@!?(\d+)>`)
+
+// SetLengthFilter only deletes messages whose content is within the given number of
+// characters, zero leaves that end of the range open
+func (c *Client) SetLengthFilter(min int, max int) {
+	c.minLength = min
+	c.maxLength = max
+}
+
+// SetMentionFilter only deletes messages mentioning at least one of the given user IDs,
+// or everyone/here for @everyone and @here
+func (c *Client) SetMentionFilter(mentions []string) {
+	c.mentions = make(map[string]bool)
+	for _, mention := range mentions {
+		c.mentions[strings.TrimPrefix(mention, "@")] = true
+	}
+}
+
+// SetEditedOnly only deletes messages which have been edited since they were sent
+func (c *Client) SetEditedOnly(editedOnly bool) {
+	c.editedOnly = editedOnly
+}
+
+// SetRepliesTo only deletes replies to the given user
+// The search is narrowed to messages mentioning them, which replies do unless the
+// ping was turned off, and each result is checked again in case the server ignores it
+func (c *Client) SetRepliesTo(id int64) {
+	c.repliesTo = strconv.FormatInt(id, 10)
+}
+
+// SetLinksOnly only deletes messages containing a link
+func (c *Client) SetLinksOnly(linksOnly bool) {
+	c.linksOnly = linksOnly
+}
+
+// Ways of filtering messages sent through webhooks
+const (
+	WebhooksOnly    = "only"
+	WebhooksExclude = "exclude"
+)
+
+// SetWebhookFilter deletes only messages sent through webhooks, or excludes them
+// An empty filter deletes messages however they were sent
+func (c *Client) SetWebhookFilter(filter string) error {
+	switch filter {
+	case "", WebhooksOnly, WebhooksExclude:
+		c.webhooks = filter
+		return nil
+	default:
+		return ErrorInvalidWebhooks
+	}
+}
+
+// SetProtectWords never deletes messages containing any of the given words, ignoring case
+// Protection takes precedence over every other filter
+func (c *Client) SetProtectWords(words []string) {
+	c.protectWords = nil
+	for _, word := range words {
+		if word != "" {
+			c.protectWords = append(c.protectWords, strings.ToLower(word))
+		}
+	}
+}
+
+// Ways of filtering replies
+const (
+	RepliesOnly    = "only"
+	RepliesExclude = "exclude"
+)
+
+// SetSkipRepliedTo leaves alone messages which someone else has replied to, as far as
+// the context around each search hit shows
+func (c *Client) SetSkipRepliedTo(skipRepliedTo bool) {
+	c.skipRepliedTo = skipRepliedTo
+}
+
+// repliedTo reports whether another user's message on the same page of results replies to
+// msg. Only the context the search returns around each hit is seen, so replies further
+// away or on other pages are missed.
+func repliedTo(msg *Message, page *Messages) bool {
+	for _, ctx := range page.ContextMessages {
+		for _, other := range ctx {
+			if other.MessageReference != nil && other.MessageReference.MessageID == msg.ID && other.Author.ID != msg.Author.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReplyFilter deletes only replies, or excludes them
+// An empty filter deletes messages whether or not they're replies
+func (c *Client) SetReplyFilter(filter string) error {
+	switch filter {
+	case "", RepliesOnly, RepliesExclude:
+		c.replies = filter
+		return nil
+	default:
+		return ErrorInvalidReplies
+	}
+}
+
+// wanted reports whether a message matches every content filter we've been given
+func (c *Client) wanted(msg *Message) bool {
+	return !c.protected(msg) && c.lengthMatches(msg) && c.mentionMatches(msg) && c.replyMatches(msg) &&
+		(!c.editedOnly || msg.EditedTimestamp != nil) &&
+		(!c.linksOnly || link.MatchString(msg.Content)) &&
+		c.webhookMatches(msg) && c.replyFilterMatches(msg) && c.sentMatches(msg)
+}
+
+// isReply reports whether a message is a reply, going by its type since the referenced
+// message is null once the original has been deleted
+func isReply(msg *Message) bool {
+	return msg.Type == UserReply
+}
+
+func (c *Client) replyFilterMatches(msg *Message) bool {
+	switch c.replies {
+	case RepliesOnly:
+		return isReply(msg)
+	case RepliesExclude:
+		return !isReply(msg)
+	default:
+		return true
+	}
+}
+
+func (c *Client) protected(msg *Message) bool {
+	content := strings.ToLower(msg.Content)
+	for _, word := range c.protectWords {
+		if strings.Contains(content, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) webhookMatches(msg *Message) bool {
+	switch c.webhooks {
+	case WebhooksOnly:
+		return msg.WebhookID != ""
+	case WebhooksExclude:
+		return msg.WebhookID == ""
+	default:
+		return true
+	}
+}
+
+func (c *Client) lengthMatches(msg *Message) bool {
+	length := utf8.RuneCountInString(msg.Content)
+
+	if c.minLength > 0 && length < c.minLength {
+		return false
+	}
+	if c.maxLength > 0 && length > c.maxLength {
+		return false
+	}
+	return true
+}
+
+func (c *Client) mentionMatches(msg *Message) bool {
+	if len(c.mentions) == 0 {
+		return true
+	}
+
+	for _, mention := range mentions(msg.Content) {
+		if c.mentions[mention] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) replyMatches(msg *Message) bool {
+	if c.repliesTo == "" {
+		return true
+	}
+
+	if msg.ReferencedMessage != nil {
+		return msg.ReferencedMessage.Author.ID == c.repliesTo
+	}
+
+	// The original may have been deleted, so fall back to who the reply pinged
+	if !isReply(msg) {
+		return false
+	}
+	for _, user := range msg.Mentions {
+		if user.ID == c.repliesTo {
+			return true
+		}
+	}
+	return false
+}
+
+// mentions returns the IDs of users mentioned in content, along with everyone or here
+func mentions(content string) []string {
+	var found []string
+
+	for _, match := range userMention.FindAllStringSubmatch(content, -1) {
+		found = append(found, match[1])
+	}
+	if strings.Contains(content, "@everyone") {
+		found = append(found, "everyone")
+	}
+	if strings.Contains(content, "@here") {
+		found = append(found, "here")
+	}
+
+	return found
+}
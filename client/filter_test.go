@@ -0,0 +1,146 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLengthFilter(t *testing.T) {
+	c := New("token")
+	c.SetLengthFilter(2, 5)
+
+	assert.False(t, c.wanted(&Message{Content: "k"}))
+	assert.True(t, c.wanted(&Message{Content: "lol"}))
+	// Counted in characters rather than bytes
+	assert.True(t, c.wanted(&Message{Content: "😂😂😂😂😂"}))
+	assert.False(t, c.wanted(&Message{Content: "a longer message"}))
+}
+
+func TestNoFilters(t *testing.T) {
+	c := New("token")
+	assert.True(t, c.wanted(&Message{Content: ""}))
+}
+
+func TestMentions(t *testing.T) {
+	found := mentions("hey <@123> and <@!456>, @everyone look")
+	assert.Equal(t, []string{"123", "456", "everyone"}, found)
+}
+
+func TestMentionFilter(t *testing.T) {
+	c := New("token")
+	c.SetMentionFilter([]string{"123", "@here"})
+
+	assert.True(t, c.wanted(&Message{Content: "<@!123> hi"}))
+	assert.True(t, c.wanted(&Message{Content: "@here meeting"}))
+	assert.False(t, c.wanted(&Message{Content: "<@456> hi"}))
+	assert.False(t, c.wanted(&Message{Content: "no pings"}))
+}
+
+func TestEditedOnly(t *testing.T) {
+	var edited, unedited Message
+	err := json.Unmarshal([]byte(`{"id":"1","edited_timestamp":"2021-03-04T05:06:07.123000+00:00"}`), &edited)
+	assert.Nil(t, err)
+	err = json.Unmarshal([]byte(`{"id":"2","edited_timestamp":null}`), &unedited)
+	assert.Nil(t, err)
+
+	c := New("token")
+	c.SetEditedOnly(true)
+
+	assert.True(t, c.wanted(&edited))
+	assert.False(t, c.wanted(&unedited))
+}
+
+func TestRepliesTo(t *testing.T) {
+	c := New("token")
+	c.SetRepliesTo(123)
+
+	reply := &Message{Type: UserReply, ReferencedMessage: &Message{Author: Recipient{ID: "123"}}}
+	assert.True(t, c.wanted(reply))
+
+	other := &Message{Type: UserReply, ReferencedMessage: &Message{Author: Recipient{ID: "456"}}}
+	assert.False(t, c.wanted(other))
+
+	// The original was deleted, but the reply still pinged them
+	orphan := &Message{Type: UserReply, Mentions: []Recipient{{ID: "123"}}}
+	assert.True(t, c.wanted(orphan))
+
+	assert.False(t, c.wanted(&Message{Type: UserMessage, Content: "<@123>"}))
+}
+
+func TestLinksOnly(t *testing.T) {
+	c := New("token")
+	c.SetLinksOnly(true)
+
+	assert.True(t, c.wanted(&Message{Content: "look at https://example.com/a?b=c"}))
+	assert.True(t, c.wanted(&Message{Content: "HTTP://EXAMPLE.COM"}))
+	assert.False(t, c.wanted(&Message{Content: "example.com"}))
+	assert.False(t, c.wanted(&Message{Content: "no links here"}))
+}
+
+func TestWebhookFilter(t *testing.T) {
+	webhook := &Message{WebhookID: "1"}
+	user := &Message{}
+
+	c := New("token")
+	assert.Nil(t, c.SetWebhookFilter(WebhooksOnly))
+	assert.True(t, c.wanted(webhook))
+	assert.False(t, c.wanted(user))
+
+	assert.Nil(t, c.SetWebhookFilter(WebhooksExclude))
+	assert.False(t, c.wanted(webhook))
+	assert.True(t, c.wanted(user))
+
+	assert.Equal(t, ErrorInvalidWebhooks, c.SetWebhookFilter("sometimes"))
+}
+
+func TestProtectWords(t *testing.T) {
+	c := New("token")
+	c.SetLinksOnly(true)
+	c.SetProtectWords([]string{"Keep", ""})
+
+	assert.True(t, c.wanted(&Message{Content: "https://example.com"}))
+	assert.False(t, c.wanted(&Message{Content: "KEEP this https://example.com"}))
+	assert.False(t, c.wanted(&Message{Content: "keep this"}))
+}
+
+func TestReplyFilter(t *testing.T) {
+	var reply, orphan, forward Message
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"1","type":19,"message_reference":{"message_id":"5","channel_id":"6"},"referenced_message":{"id":"5"}}`), &reply))
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"2","type":19,"message_reference":{"message_id":"7","channel_id":"6"},"referenced_message":null}`), &orphan))
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"3","type":0,"message_reference":{"message_id":"8","channel_id":"9"}}`), &forward))
+	assert.Equal(t, "5", reply.MessageReference.MessageID)
+
+	c := New("token")
+	assert.Nil(t, c.SetReplyFilter(RepliesOnly))
+	assert.True(t, c.wanted(&reply))
+	assert.True(t, c.wanted(&orphan))
+	assert.False(t, c.wanted(&forward))
+
+	assert.Nil(t, c.SetReplyFilter(RepliesExclude))
+	assert.False(t, c.wanted(&reply))
+	assert.True(t, c.wanted(&forward))
+
+	assert.Equal(t, ErrorInvalidReplies, c.SetReplyFilter("sometimes"))
+}
+
+func TestSkipRepliedTo(t *testing.T) {
+	mine := Message{ID: "1", Hit: true, Author: Recipient{ID: "me"}}
+	page := &Messages{ContextMessages: [][]Message{
+		{mine, {ID: "2", Author: Recipient{ID: "friend"}, MessageReference: &MessageReference{MessageID: "1"}}},
+		{{ID: "3", Hit: true, Author: Recipient{ID: "me"}}, {ID: "4", Author: Recipient{ID: "me"}, MessageReference: &MessageReference{MessageID: "3"}}},
+	}}
+
+	assert.True(t, repliedTo(&mine, page))
+	// Replying to ourselves doesn't make it a conversation
+	assert.False(t, repliedTo(&page.ContextMessages[1][0], page))
+
+	var seek int
+	c := New("token")
+	c.SetDryRun(true)
+	c.SetSkipRepliedTo(true)
+	err := c.DeleteMessages(page, &seek, newPageTracker())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), c.DeletedCount())
+	assert.Equal(t, 2, seek)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Ways of handling the server refusing us permission to search a channel or delete a message
+const (
+	// ForbiddenSkip moves on, reporting what was skipped in the summary
+	ForbiddenSkip = "skip"
+	// ForbiddenFail ends the run with the error
+	ForbiddenFail = "fail"
+)
+
+// SetForbidden chooses what happens when we're forbidden from searching a channel or
+// deleting a message. Either way, a 403 is never mistaken for an empty channel or a
+// successful deletion.
+func (c *Client) SetForbidden(mode string) error {
+	switch mode {
+	case ForbiddenSkip, ForbiddenFail:
+		c.forbidden = mode
+		return nil
+	default:
+		return ErrorInvalidForbidden
+	}
+}
+
+func forbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// channelForbidden reports whether searching a channel failed for lack of permission
+// and it should be skipped, e.g. a guild channel we can no longer read
+func (c *Client) channelForbidden(channel *Channel, err error) bool {
+	// A guild with search disabled is handled by the guild, rather than skipping each channel
+	if !forbidden(err) || searchDisabled(err) || c.forbidden == ForbiddenFail {
+		return false
+	}
+
+	log.Debugf("No permission to search channel %v, skipping", channel.ID)
+	c.forbiddenChannels.add(channel.ID)
+	return true
+}
+
+// deleteForbidden reports whether deleting a message failed for lack of permission
+// and it should be skipped. Only the message is skipped, the rest of its channel is
+// still tried.
+func (c *Client) deleteForbidden(msg *Message, err error) bool {
+	if !forbidden(err) || c.forbidden == ForbiddenFail {
+		return false
+	}
+
+	log.Warnf("No permission to delete message %v from channel %v, skipping", msg.ID, msg.ChannelID)
+	atomic.AddInt64(&c.forbiddenCount, 1)
+	c.refusedChannels.add(msg.ChannelID)
+	return true
+}
+
+func (c *Client) logForbidden() {
+	if channels := c.forbiddenChannels.list(); len(channels) > 0 {
+		log.Infof("Skipped %v channels we don't have permission to search", len(channels))
+		log.Debugf("Channels we don't have permission to search: %v", strings.Join(channels, ", "))
+	}
+	if count := atomic.LoadInt64(&c.forbiddenCount); count > 0 {
+		log.Warnf("Skipped %v messages we don't have permission to delete", count)
+		log.Infof("Channels which refused deletions: %v", strings.Join(c.refusedChannels.list(), ", "))
+	}
+}
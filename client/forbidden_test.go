@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSearchForbidden(t *testing.T) {
+	server := searchServer(map[string]int{"1": 30}, 1)
+	defer server.Close()
+
+	me := &Me{ID: "me"}
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	// The first page is fine, the second is forbidden
+	err := c.DeleteFromChannel(me, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(messageLimit), c.DeletedCount())
+	assert.Equal(t, []string{"1"}, c.forbiddenChannels.list())
+
+	c = New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	assert.Nil(t, c.SetForbidden(ForbiddenFail))
+
+	err = c.DeleteFromChannel(me, &Channel{ID: "1"})
+	assert.True(t, forbidden(err))
+	assert.Empty(t, c.forbiddenChannels.list())
+
+	assert.Equal(t, ErrorInvalidForbidden, c.SetForbidden("ignore"))
+}
+
+func TestDeleteForbidden(t *testing.T) {
+	search := searchServer(map[string]int{"1": 3}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "DELETE" && r.URL.Path == "/channels/1/messages/10001":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	messages := []Message{{ID: "10000", ChannelID: "1"}, {ID: "10001", ChannelID: "1"}, {ID: "10002", ChannelID: "1"}}
+
+	c := New("token")
+	c.baseURL = server.URL
+	err := c.DeleteFromPackage(messages)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), c.DeletedCount())
+	assert.Equal(t, int64(1), c.forbiddenCount)
+
+	c = New("token")
+	c.baseURL = server.URL
+	assert.Nil(t, c.SetForbidden(ForbiddenFail))
+	err = c.DeleteFromPackage(messages)
+	assert.True(t, forbidden(errors.Cause(err)))
+	assert.Equal(t, int64(1), c.DeletedCount())
+}
+
+func TestRefusedMessageSkipped(t *testing.T) {
+	var mu sync.Mutex
+	remaining := []Message{
+		{ID: "10004", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}},
+		{ID: "10003", ChannelID: "6", Hit: true, Author: Recipient{ID: "me"}},
+		{ID: "10002", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}},
+		{ID: "10001", ChannelID: "6", Hit: true, Author: Recipient{ID: "me"}},
+		{ID: "10000", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}, Type: ChannelPinnedMessage},
+	}
+	refusals := map[string]int{"10004": http.StatusForbidden, "10000": http.StatusForbidden}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "DELETE":
+			for i, msg := range remaining {
+				if !strings.HasSuffix(r.URL.Path, "/"+msg.ID) {
+					continue
+				}
+				if status, ok := refusals[msg.ID]; ok {
+					w.WriteHeader(status)
+					return
+				}
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			results := Messages{TotalResults: len(remaining)}
+			for i := offset; i < len(remaining); i++ {
+				results.ContextMessages = append(results.ContextMessages, []Message{remaining[i]})
+			}
+			json.NewEncoder(w).Encode(results)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	// One refusal doesn't give up on the rest of the channel
+	c := New("token")
+	c.baseURL = server.URL
+	err := c.DeleteFromGuild(&Me{ID: "me"}, &Channel{ID: "1", Name: "guild"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), c.DeletedCount())
+	assert.Equal(t, []string{"5"}, c.refusedChannels.list())
+	assert.Len(t, remaining, 2)
+}
+
+func TestRefusedSystemMessageKeepsChannel(t *testing.T) {
+	var mu sync.Mutex
+	remaining := []Message{
+		{ID: "10002", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}, Type: ChannelPinnedMessage},
+		{ID: "10001", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}},
+		{ID: "10000", ChannelID: "5", Hit: true, Author: Recipient{ID: "me"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "DELETE" && strings.HasSuffix(r.URL.Path, "/10002"):
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == "DELETE":
+			for i, msg := range remaining {
+				if strings.HasSuffix(r.URL.Path, "/"+msg.ID) {
+					remaining = append(remaining[:i], remaining[i+1:]...)
+					break
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			results := Messages{TotalResults: len(remaining)}
+			for i := offset; i < len(remaining); i++ {
+				results.ContextMessages = append(results.ContextMessages, []Message{remaining[i]})
+			}
+			json.NewEncoder(w).Encode(results)
+		}
+	}))
+	defer server.Close()
+
+	// The refused pin is left behind, but the normal messages after it are still deleted
+	c := New("token")
+	c.baseURL = server.URL
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "5"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), c.DeletedCount())
+	assert.Len(t, remaining, 1)
+
+	// Refused as a system message, rather than counted against the channel's permissions
+	assert.Equal(t, int64(0), c.forbiddenCount)
+	assert.Empty(t, c.refusedChannels.list())
+}
@@ -0,0 +1,27 @@
+package client
+
+// Deletions are one at a time by default, even when channels are processed concurrently
+const defaultMaxInFlight = 1
+
+// SetMaxInFlight caps how many deletions can be waiting on a response at once, however
+// many channels are being searched concurrently
+func (c *Client) SetMaxInFlight(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.inFlight = make(chan struct{}, n)
+}
+
+// acquireDelete waits for a free slot for a deletion, or for the run to be cancelled
+func (c *Client) acquireDelete() error {
+	select {
+	case c.inFlight <- struct{}{}:
+		return nil
+	case <-c.context().Done():
+		return ErrorInterrupted
+	}
+}
+
+func (c *Client) releaseDelete() {
+	<-c.inFlight
+}
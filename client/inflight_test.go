@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	var current, highest int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			seen := atomic.LoadInt32(&highest)
+			if now <= seen || atomic.CompareAndSwapInt32(&highest, seen, now) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	for _, limit := range []int{defaultMaxInFlight, 3} {
+		atomic.StoreInt32(&highest, 0)
+
+		c := New("token")
+		c.baseURL = server.URL
+		if limit != defaultMaxInFlight {
+			c.SetMaxInFlight(limit)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := c.DeleteMessage(&Message{ID: fmt.Sprint(1000 + i), ChannelID: "1"})
+				assert.Nil(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.True(t, atomic.LoadInt32(&highest) <= int32(limit))
+		assert.True(t, atomic.LoadInt32(&highest) >= 1)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrorInterrupted is returned once the run's context has been cancelled, e.g. by Ctrl-C
+var ErrorInterrupted = errors.New("Interrupted")
+
+// SetContext lets the run be cancelled, waking it from any sleep straight away
+func (c *Client) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// interrupted returns ErrorInterrupted if the run has been cancelled
+func (c *Client) interrupted() error {
+	if c.context().Err() != nil {
+		return ErrorInterrupted
+	}
+	return nil
+}
+
+// sleep waits for d, unless the run is cancelled first, in which case it returns
+// ErrorInterrupted straight away rather than waiting the rest out
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ErrorInterrupted
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Client) sleep(d time.Duration) error {
+	return sleep(c.context(), d)
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+)
+
+// Kinds of entry in a job
+const (
+	JobChannel      = "channel"
+	JobRelationship = "relationship"
+	JobGuild        = "guild"
+)
+
+// Job lists everywhere there are messages to delete, so that discovery can be done
+// once and the deletion split up across machines or sessions. Entries can be divided
+// between several job files, each of which is then passed to a separate run.
+type Job struct {
+	Entries  []JobEntry `json:"entries"`
+	Manifest *Manifest  `json:"manifest"`
+}
+
+// JobEntry is a channel, guild or user whose DM needs reopening, along with
+// roughly how many of our messages the search reported in it when planned
+type JobEntry struct {
+	Kind     string `json:"kind"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Estimate int    `json:"estimate"`
+}
+
+// ReadJob parses a job written by Plan
+func ReadJob(r io.Reader) (*Job, error) {
+	var job Job
+	err := json.NewDecoder(r).Decode(&job)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing job")
+	}
+
+	for _, entry := range job.Entries {
+		switch entry.Kind {
+		case JobChannel, JobRelationship, JobGuild:
+		default:
+			return nil, fmt.Errorf("Unknown kind of job entry '%v' for %v", entry.Kind, entry.ID)
+		}
+	}
+
+	return &job, nil
+}
+
+// Plan finds everywhere there are messages to delete without deleting anything,
+// or reopening any DMs. DMs which are closed are listed by the user they're with.
+func (c *Client) Plan() (*Job, error) {
+	me, err := c.Me()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching profile information")
+	}
+
+	manifest, err := c.buildManifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error building manifest")
+	}
+	job := &Job{Manifest: manifest}
+
+	channels, err := c.Channels()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching channels")
+	}
+	open := make(map[string]bool)
+	for _, channel := range channels {
+		if channel.Type == DirectChannel && len(channel.Recipients) == 1 {
+			open[channel.Recipients[0].ID] = true
+		}
+		if c.skipChannel(channel.ID) {
+			continue
+		}
+
+		estimate, err := c.estimate("channel_msgs", &channel, me)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error estimating messages for channel")
+		}
+		if estimate > 0 {
+			job.Entries = append(job.Entries, JobEntry{JobChannel, channel.ID, manifest.Channels[channel.ID], estimate})
+		}
+	}
+
+	if !c.skipRelationships && !c.noReopenDMs {
+		relationships, err := c.Relationships()
+		if err != nil && !hasStatus(err, http.StatusForbidden) {
+			return nil, errors.Wrap(err, "Error fetching relationships")
+		}
+		for _, relation := range relationships {
+			if (open[relation.Recipient.ID] && !c.noRelationDedup) || !c.relationWanted(relation) {
+				continue
+			}
+			// There's no way of knowing what's in a closed DM without reopening it
+			job.Entries = append(job.Entries, JobEntry{JobRelationship, relation.Recipient.ID, relation.Recipient.String(), 0})
+		}
+	}
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching guilds")
+	}
+	for _, guild := range guilds {
+		if c.skipChannel(guild.ID) || !c.targetGuild(guild.ID) {
+			continue
+		}
+
+		estimate, err := c.estimate("guild_msgs", &guild, me)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error estimating messages for guild")
+		}
+		if estimate > 0 {
+			job.Entries = append(job.Entries, JobEntry{JobGuild, guild.ID, guild.Name, estimate})
+		}
+	}
+
+	return job, nil
+}
+
+// estimate returns the number of results the search reports within our bounds
+func (c *Client) estimate(search string, channel *Channel, me *Me) (int, error) {
+	endpoint := fmt.Sprintf(endpoints[search], channel.ID, me.ID, 0, 1)
+	endpoint = c.withBounds(endpoint, 0)
+
+	var results Messages
+	err := c.request("GET", endpoint, nil, &results)
+	if err != nil {
+		return 0, err
+	}
+
+	return results.TotalResults, nil
+}
+
+// DeleteFromJob deletes from only the entries in the job
+func (c *Client) DeleteFromJob(job *Job) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range job.Entries {
+		log.Infof("Deleting from %v %v '%v', estimated %v messages", entry.Kind, entry.ID, entry.Name, entry.Estimate)
+
+		switch entry.Kind {
+		case JobChannel:
+			err = c.DeleteFromChannel(me, &Channel{ID: entry.ID, Name: entry.Name})
+		case JobGuild:
+			err = c.DeleteFromGuild(me, &Channel{ID: entry.ID, Name: entry.Name})
+		case JobRelationship:
+			var channel *Channel
+			channel, err = c.ChannelRelationship(&Recipient{ID: entry.ID, Username: entry.Name})
+			if err != nil {
+				return errors.Wrap(err, "Error resolving relationship to channel")
+			}
+			err = c.DeleteFromChannel(me, channel)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logSummary()
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadJob(t *testing.T) {
+	job, err := ReadJob(strings.NewReader(`{"entries":[{"kind":"guild","id":"1","name":"a","estimate":40},{"kind":"relationship","id":"2"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []JobEntry{
+		{Kind: JobGuild, ID: "1", Name: "a", Estimate: 40},
+		{Kind: JobRelationship, ID: "2"},
+	}, job.Entries)
+
+	_, err = ReadJob(strings.NewReader(`{"entries":[{"kind":"server","id":"1"}]}`))
+	assert.NotNil(t, err)
+}
+
+// jobServer has an open DM with user 1, a closed one with user 2 and two guilds
+func jobServer(reopened *int) *httptest.Server {
+	search := searchServer(map[string]int{"11": 3, "12": 0, "13": 2, "21": 4, "22": 5}, 0)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"me"}`)
+		case r.Method == "POST" && r.URL.Path == "/users/@me/channels":
+			*reopened++
+			fmt.Fprint(w, `{"id":"13","type":1,"recipients":[{"id":"2","username":"closed"}]}`)
+		case r.URL.Path == "/users/@me/channels":
+			fmt.Fprint(w, `[{"id":"11","type":1,"recipients":[{"id":"1","username":"open"}]},{"id":"12","type":1,"recipients":[{"id":"3","username":"empty"}]}]`)
+		case r.URL.Path == "/users/@me/relationships":
+			fmt.Fprint(w, `[{"id":"1","type":1,"user":{"id":"1","username":"open"}},{"id":"2","type":1,"user":{"id":"2","username":"closed"}}]`)
+		case r.URL.Path == "/users/@me/guilds":
+			fmt.Fprint(w, `[{"id":"21","name":"kept"},{"id":"22","name":"skipped"}]`)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			search.Config.Handler.ServeHTTP(w, r)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+}
+
+func TestPlan(t *testing.T) {
+	reopened := 0
+	server := jobServer(&reopened)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetSkipChannels([]string{"22"})
+
+	job, err := c.Plan()
+	assert.Nil(t, err)
+	// The empty DM is left out, and the closed one is listed by user without reopening it
+	assert.Equal(t, []JobEntry{
+		{Kind: JobChannel, ID: "11", Name: "open", Estimate: 3},
+		{Kind: JobRelationship, ID: "2", Name: "closed"},
+		{Kind: JobGuild, ID: "21", Name: "kept", Estimate: 4},
+	}, job.Entries)
+	assert.Equal(t, 0, reopened)
+	assert.Equal(t, int64(0), c.DeletedCount())
+	assert.Equal(t, "skipped", job.Manifest.Guilds["22"])
+
+	// Closed DMs can't be planned without reopening them
+	c.SetNoReopenDMs(true)
+	job, err = c.Plan()
+	assert.Nil(t, err)
+	assert.Len(t, job.Entries, 2)
+}
+
+func TestDeleteFromJob(t *testing.T) {
+	reopened := 0
+	server := jobServer(&reopened)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	// Only what's in the job, so the other DM and guild aren't touched
+	err := c.DeleteFromJob(&Job{Entries: []JobEntry{
+		{Kind: JobRelationship, ID: "2", Name: "closed"},
+		{Kind: JobGuild, ID: "21", Name: "kept", Estimate: 4},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reopened)
+	assert.Equal(t, int64(6), c.DeletedCount())
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// ErrorLoginRejected is returned when Discord refuses the credentials, or wants a captcha solved
+var ErrorLoginRejected = errors.New("Discord rejected the login, check the email, password and code, or log in through the Discord client if it asks for a captcha")
+
+type loginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type mfaRequest struct {
+	Code   string `json:"code"`
+	Ticket string `json:"ticket"`
+}
+
+// loginResponse holds either a token, or a ticket for the second step when the
+// account has two factor authentication enabled
+type loginResponse struct {
+	Token  string `json:"token"`
+	MFA    bool   `json:"mfa"`
+	Ticket string `json:"ticket"`
+}
+
+// Login exchanges an email and password for a token, which the client then uses
+// If the account has two factor authentication enabled, code is called for the
+// current code from the authenticator app. Neither the credentials nor the token
+// are kept anywhere other than in memory.
+func (c *Client) Login(email string, password string, code func() (string, error)) (string, error) {
+	var res loginResponse
+	err := c.strictRequest("POST", endpoints["login"], &loginRequest{email, password}, &res)
+	if err != nil {
+		return "", loginError(err)
+	}
+
+	if res.MFA {
+		mfaCode, err := code()
+		if err != nil {
+			return "", errors.Wrap(err, "Error reading two factor code")
+		}
+
+		ticket := res.Ticket
+		res = loginResponse{}
+		err = c.strictRequest("POST", endpoints["mfa_totp"], &mfaRequest{mfaCode, ticket}, &res)
+		if err != nil {
+			return "", loginError(err)
+		}
+	}
+
+	if res.Token == "" {
+		return "", ErrorLoginRejected
+	}
+
+	c.token = res.Token
+	return res.Token, nil
+}
+
+// loginError replaces the status codes Discord uses for bad credentials with something clearer
+func loginError(err error) error {
+	if hasStatus(err, http.StatusBadRequest) || errors.Cause(err) == ErrorUnauthorized {
+		return ErrorLoginRejected
+	}
+	return errors.Wrap(err, "Error logging in")
+}
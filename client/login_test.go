@@ -0,0 +1,47 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoginWithMFA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/auth/login":
+			var req loginRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Password != "hunter2" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(loginResponse{MFA: true, Ticket: "ticket"})
+		case "/auth/mfa/totp":
+			var req mfaRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Code != "123456" || req.Ticket != "ticket" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(loginResponse{Token: "token"})
+		}
+	}))
+	defer server.Close()
+
+	code := func() (string, error) { return "123456", nil }
+
+	c := New("")
+	c.baseURL = server.URL
+	_, err := c.Login("someone@example.com", "wrong", code)
+	assert.Equal(t, ErrorLoginRejected, err)
+
+	tok, err := c.Login("someone@example.com", "hunter2", code)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", tok)
+	assert.Equal(t, "token", c.token)
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+// Manifest maps the IDs that appear in logs and exports to human readable names
+type Manifest struct {
+	Channels map[string]string `json:"channels"`
+	Guilds   map[string]string `json:"guilds"`
+	Users    map[string]string `json:"users"`
+}
+
+func (c *Client) SetManifestPath(path string) {
+	c.manifestPath = path
+}
+
+func (c *Client) buildManifest() (*Manifest, error) {
+	manifest := &Manifest{
+		Channels: make(map[string]string),
+		Guilds:   make(map[string]string),
+		Users:    make(map[string]string),
+	}
+
+	channels, err := c.Channels()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching channels")
+	}
+	for _, channel := range channels {
+		for _, recipient := range channel.Recipients {
+			manifest.Users[recipient.ID] = recipient.String()
+		}
+		manifest.Channels[channel.ID] = dmName(&channel)
+	}
+
+	relationships, err := c.Relationships()
+	if err != nil && !hasStatus(err, http.StatusForbidden) {
+		return nil, errors.Wrap(err, "Error fetching relationships")
+	}
+	for _, relation := range relationships {
+		manifest.Users[relation.Recipient.ID] = relation.Recipient.String()
+	}
+
+	guilds, err := c.Guilds()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching guilds")
+	}
+	for _, guild := range guilds {
+		manifest.Guilds[guild.ID] = guild.Name
+	}
+
+	return manifest, nil
+}
+
+// writeManifest writes the manifest to the manifest path, if one was set
+func (c *Client) writeManifest() error {
+	if c.manifestPath == "" {
+		return nil
+	}
+
+	manifest, err := c.buildManifest()
+	if err != nil {
+		return errors.Wrap(err, "Error building manifest")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error encoding manifest")
+	}
+	err = WriteFileAtomic(c.manifestPath, append(data, '\n'), 0600)
+	if err != nil {
+		return errors.Wrap(err, "Error writing manifest")
+	}
+
+	log.Infof("Wrote manifest of %v channels, %v guilds and %v users to %v", len(manifest.Channels), len(manifest.Guilds), len(manifest.Users), c.manifestPath)
+
+	return nil
+}
+
+// dmName names a DM or group DM the way Discord does, by its name if it's been given one
+// or otherwise by who's in it
+func dmName(channel *Channel) string {
+	if channel.Name != "" {
+		return channel.Name
+	}
+
+	var names []string
+	for _, recipient := range channel.Recipients {
+		names = append(names, recipient.String())
+	}
+	return strings.Join(names, ", ")
+}
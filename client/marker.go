@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Marker records that a full run left an account without any messages, so that
+// later runs over the same account can be skipped
+type Marker struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Cleaned  time.Time `json:"cleaned"`
+	Deleted  int64     `json:"deleted"`
+}
+
+// SetMarkerDir stores a marker per account in dir, leaving it empty disables markers
+func (c *Client) SetMarkerDir(dir string) {
+	c.markerDir = dir
+}
+
+// SetForce runs over accounts even if they were left clean by a previous run
+func (c *Client) SetForce(force bool) {
+	c.force = force
+}
+
+func (c *Client) markerPath(me *Me) string {
+	return filepath.Join(c.markerDir, me.ID+".json")
+}
+
+// alreadyClean reports whether a previous run marked this account as clean
+func (c *Client) alreadyClean(me *Me) bool {
+	if c.markerDir == "" || c.force {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(c.markerPath(me))
+	if err != nil {
+		return false
+	}
+
+	var marker Marker
+	err = json.Unmarshal(data, &marker)
+	if err != nil {
+		log.Warnf("Ignoring unreadable marker for account %v: %v", me.ID, err)
+		return false
+	}
+
+	log.Infof("Account %v was left clean by a run at %v, skipping (pass --force to run anyway)", me.Username, marker.Cleaned.Format(time.RFC3339))
+	return true
+}
+
+// fullRun reports whether the run covered everything, so nothing could be left
+// behind by a filter, bound or skip
+func (c *Client) fullRun() bool {
+	return !c.dryRun &&
+		c.minID == 0 && c.maxID == 0 &&
+		c.minLength == 0 && c.maxLength == 0 &&
+		len(c.mentions) == 0 && len(c.protectWords) == 0 && !c.editedOnly && c.hours == nil && c.days == nil && !c.skipRepliedTo && c.readFilter == "" && c.repliesTo == "" && !c.linksOnly && c.webhooks == "" && c.replies == "" &&
+		c.dormantAge == 0 && c.maxPerChannel == 0 && c.maxDeletions == 0 && c.oldestPercent == 0 &&
+		len(c.skipChannels) == 0 && len(c.guilds) == 0 &&
+		c.startPhase == PhaseChannels && !c.skipRelationships && !c.noReopenDMs && len(c.relationshipTypes) == 0
+}
+
+// markClean writes the marker if the run covered everything and deleted all it found
+func (c *Client) markClean(me *Me) error {
+	if c.markerDir == "" || !c.fullRun() {
+		return nil
+	}
+	if atomic.LoadInt64(&c.foundCount) != c.DeletedCount() || len(c.failedRelations) > 0 || c.relationsForbidden || len(c.inaccessibleGuilds) > 0 || len(c.unsearchableGuilds) > 0 || len(c.disabledChannels.list()) > 0 || len(c.forbiddenChannels.list()) > 0 ||
+		len(c.systemChannels.list()) > 0 || atomic.LoadInt64(&c.steppedOver) > 0 ||
+		atomic.LoadInt64(&c.unreadPages) > 0 {
+		return nil
+	}
+
+	marker := Marker{
+		UserID:   me.ID,
+		Username: me.Username,
+		Cleaned:  time.Now().UTC(),
+		Deleted:  c.DeletedCount(),
+	}
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error encoding marker")
+	}
+
+	err = os.MkdirAll(c.markerDir, 0700)
+	if err != nil {
+		return errors.Wrap(err, "Error creating marker directory")
+	}
+	err = WriteFileAtomic(c.markerPath(me), data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Error writing marker")
+	}
+
+	log.Infof("Marked account %v as clean", me.Username)
+	return nil
+}
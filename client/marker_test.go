@@ -0,0 +1,58 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMarker(t *testing.T) {
+	me := &Me{ID: "1", Username: "someone"}
+
+	c := New("token")
+	c.SetMarkerDir(t.TempDir())
+	assert.False(t, c.alreadyClean(me))
+
+	err := c.markClean(me)
+	assert.Nil(t, err)
+	assert.True(t, c.alreadyClean(me))
+
+	c.SetForce(true)
+	assert.False(t, c.alreadyClean(me))
+}
+
+func TestMarkerSkipsPartialRuns(t *testing.T) {
+	me := &Me{ID: "1", Username: "someone"}
+
+	c := New("token")
+	c.SetMarkerDir(t.TempDir())
+	c.SetSkipChannels([]string{"2"})
+
+	err := c.markClean(me)
+	assert.Nil(t, err)
+	assert.False(t, c.alreadyClean(me))
+}
+
+func TestMarkerSkipsClosedDMs(t *testing.T) {
+	me := &Me{ID: "1", Username: "someone"}
+
+	c := New("token")
+	c.SetMarkerDir(t.TempDir())
+	c.SetNoReopenDMs(true)
+
+	err := c.markClean(me)
+	assert.Nil(t, err)
+	assert.False(t, c.alreadyClean(me))
+}
+
+func TestMarkerSkipsSystemChannels(t *testing.T) {
+	me := &Me{ID: "1", Username: "someone"}
+
+	// Skipped by default, so a guild's #general can be left untouched
+	c := New("token")
+	c.SetMarkerDir(t.TempDir())
+	c.systemChannels.add("2")
+
+	err := c.markClean(me)
+	assert.Nil(t, err)
+	assert.False(t, c.alreadyClean(me))
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// waitForNetwork pauses the whole run until the API is reachable again, giving up
+// once the maximum wait has passed. It reports whether the network came back.
+func (c *Client) waitForNetwork() bool {
+	const minInterval = 5 * time.Second
+	const maxInterval = 30 * time.Second
+
+	log.Warnf("Lost connection to Discord, waiting up to %v for it to come back", c.networkWait)
+
+	deadline := time.Now().Add(c.networkWait)
+	interval := minInterval
+
+	for time.Now().Before(deadline) {
+		if c.sleep(interval) != nil {
+			return false
+		}
+
+		if c.healthy() {
+			log.Infof("Connection to Discord restored, resuming")
+			return true
+		}
+
+		log.Debugf("Discord is still unreachable, checking again in %v", interval)
+
+		// Back off so that we aren't hammering a connection that's struggling
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+
+	log.Errorf("Connection to Discord wasn't restored within %v", c.networkWait)
+	return false
+}
+
+// Reachable reports whether the API can be reached at all, regardless of whether we're authorised
+func (c *Client) Reachable() bool {
+	return c.healthy()
+}
+
+// healthy checks whether the API is reachable, any response at all means the network is back
+func (c *Client) healthy() bool {
+	req, err := http.NewRequest("GET", c.baseURL+endpoints["me"], nil)
+	if err != nil {
+		return false
+	}
+	c.setHeaders(req)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+
+	return true
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"strconv"
+)
+
+var ErrorInvalidPercent = errors.New("Percentage must be more than 0 and at most 100")
+
+// SetOldestPercent only deletes the oldest percent of our messages in each channel or
+// guild, so that repeated runs shrink the account's footprint gradually. Zero deletes
+// everything as usual.
+func (c *Client) SetOldestPercent(percent float64) error {
+	if percent < 0 || percent > 100 {
+		return ErrorInvalidPercent
+	}
+	c.oldestPercent = percent
+	return nil
+}
+
+// oldestCount is how many of total messages make up the oldest percent, rounded up so
+// that small channels still shrink rather than being left alone forever
+func oldestCount(total int, percent float64) int {
+	return int(math.Ceil(float64(total) * percent / 100))
+}
+
+// maxSearchOffset is the deepest offset Discord's search accepts, deeper pages are rejected
+const maxSearchOffset = 5000
+
+// oldestCutoff finds the max_id bound which leaves just the oldest percent of our messages
+// in the channel to delete. Search results are newest first, so the newest message to go
+// sits at offset total - count. Zero means no cutoff is needed.
+func (c *Client) oldestCutoff(search string, channel *Channel, me *Me) (int64, error) {
+	if c.oldestPercent == 0 || c.oldestPercent == 100 {
+		return 0, nil
+	}
+
+	first, err := c.searchAt(search, channel, me, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	total := first.TotalResults
+	count := oldestCount(total, c.oldestPercent)
+	if count >= total {
+		return 0, nil
+	}
+
+	offset := total - count
+	cursor := int64(0)
+	if offset > maxSearchOffset {
+		newest, err := firstHit(first)
+		if err != nil {
+			return 0, err
+		}
+		cursor, offset, err = c.narrowOldest(search, channel, me, count, newest+1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	page, err := c.searchAt(search, channel, me, offset, cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := firstHit(page)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		// The index moved under us, so there's nothing sensible to bound the pass with
+		return 0, fmt.Errorf("No message found at offset %v of %v", total-count, total)
+	}
+
+	log.Infof("Deleting the oldest %v of %v messages in %v", count, total, channel.ID)
+	// max_id is exclusive, so step past the newest message we want gone
+	return id + 1, nil
+}
+
+// narrowOldest bisects on max_id until the newest of the oldest count messages is within
+// the search's offset limit, returning the max_id to search below and its offset there
+func (c *Client) narrowOldest(search string, channel *Channel, me *Me, count int, newest int64) (int64, int, error) {
+	// A max_id of zero means no bound at all, so start just above it
+	lo, hi := int64(1), newest
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		results, err := c.searchAt(search, channel, me, 0, mid)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		below := results.TotalResults
+		switch {
+		case below < count:
+			lo = mid + 1
+		case below-count > maxSearchOffset:
+			hi = mid
+		default:
+			return mid, below - count, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("Couldn't narrow the search down to the oldest %v messages in %v", count, channel.ID)
+}
+
+// firstHit returns the ID of the first hit on a page, or zero if there isn't one
+func firstHit(page *Messages) (int64, error) {
+	for _, ctx := range page.ContextMessages {
+		for _, msg := range ctx {
+			if !msg.Hit {
+				continue
+			}
+			id, err := strconv.ParseInt(msg.ID, 10, 64)
+			if err != nil {
+				return 0, errors.Wrap(err, "Error parsing message ID")
+			}
+			return id, nil
+		}
+	}
+	return 0, nil
+}
+
+// searchAt fetches a single search result at the given offset, below cursor if it's set
+// and within the run's bounds
+func (c *Client) searchAt(search string, channel *Channel, me *Me, offset int, cursor int64) (*Messages, error) {
+	endpoint := c.withBounds(fmt.Sprintf(endpoints[search], channel.ID, me.ID, offset, 1), cursor)
+
+	var results Messages
+	err := c.searchRequest(endpoint, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return &results, nil
+}
+
+// boundOldest applies the oldest percent cutoff to a pass, unless the checkpoint has
+// already taken it further back
+func (pages *pageTracker) boundOldest(cutoff int64) {
+	if cutoff > 0 && (pages.cursor == 0 || cutoff < pages.cursor) {
+		pages.cursor = cutoff
+	}
+}
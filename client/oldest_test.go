@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOldestCount(t *testing.T) {
+	assert.Equal(t, 0, oldestCount(0, 20))
+	assert.Equal(t, 1, oldestCount(3, 20))
+	assert.Equal(t, 2, oldestCount(10, 20))
+	assert.Equal(t, 3, oldestCount(10, 25))
+	assert.Equal(t, 10, oldestCount(10, 100))
+}
+
+func TestOldestPercent(t *testing.T) {
+	var mu sync.Mutex
+	remaining := map[int64]bool{}
+	for id := int64(1001); id <= 1010; id++ {
+		remaining[id] = true
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == "DELETE" {
+			parts := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+			delete(remaining, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Newest first, like the real search
+		maxID, _ := strconv.ParseInt(r.URL.Query().Get("max_id"), 10, 64)
+		var ids []int64
+		for id := range remaining {
+			if maxID == 0 || id < maxID {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		results := Messages{TotalResults: len(ids)}
+		for i := offset; i < len(ids) && i < offset+limit; i++ {
+			results.ContextMessages = append(results.ContextMessages, []Message{{
+				ID:        fmt.Sprint(ids[i]),
+				Hit:       true,
+				ChannelID: "1",
+				Type:      UserMessage,
+				Author:    Recipient{ID: "me"},
+			}})
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	assert.Nil(t, c.SetOldestPercent(20))
+	assert.Equal(t, ErrorInvalidPercent, c.SetOldestPercent(120))
+	assert.False(t, c.fullRun())
+
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), c.DeletedCount())
+	assert.False(t, remaining[1001])
+	assert.False(t, remaining[1002])
+	assert.True(t, remaining[1003])
+}
+
+func TestOldestCutoffPastOffsetLimit(t *testing.T) {
+	var ids []int64
+	for id := int64(13000); id > 1000; id-- {
+		ids = append(ids, id)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like Discord, refuse to page this deep rather than answering
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset > maxSearchOffset {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"Invalid Form Body","code":50035}`)
+			return
+		}
+
+		maxID, _ := strconv.ParseInt(r.URL.Query().Get("max_id"), 10, 64)
+		var below []int64
+		for _, id := range ids {
+			if maxID == 0 || id < maxID {
+				below = append(below, id)
+			}
+		}
+
+		results := Messages{TotalResults: len(below)}
+		if offset < len(below) {
+			results.ContextMessages = [][]Message{{{ID: fmt.Sprint(below[offset]), Hit: true, ChannelID: "1"}}}
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	assert.Nil(t, c.SetOldestPercent(20))
+
+	// The oldest 2400 of 12000 run up to 3400, far deeper than a single search can reach
+	cutoff, err := c.oldestCutoff("channel_msgs", &Channel{ID: "1"}, &Me{ID: "me"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3401), cutoff)
+}
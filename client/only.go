@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Channel fetches a single channel by ID
+func (c *Client) Channel(id string) (*Channel, error) {
+	endpoint := fmt.Sprintf(endpoints["channel"], id)
+	var channel Channel
+	err := c.strictRequest("GET", endpoint, nil, &channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &channel, nil
+}
+
+// DeleteFromChannelIDs deletes only from the given channels, fetching each directly
+// rather than listing every channel, relationship and guild, so a quick check of one
+// channel (e.g. with a dry run and a limit) only costs a few requests
+func (c *Client) DeleteFromChannelIDs(ids []string) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		channel, err := c.Channel(id)
+		if hasStatus(err, http.StatusNotFound, http.StatusForbidden) {
+			return fmt.Errorf("No channel with ID %v that we can read", id)
+		}
+		if err != nil {
+			return errors.Wrap(err, "Error fetching channel")
+		}
+
+		log.Infof("Deleting from channel %v only", id)
+		before := c.DeletedCount()
+		err = c.DeleteFromChannel(me, channel)
+		if err != nil {
+			return err
+		}
+		log.Infof("Channel %v: %v messages deleted", id, c.DeletedCount()-before)
+	}
+
+	c.logSummary()
+
+	return nil
+}
+
+// ReadChannelIDs reads channel IDs from a file, one per line, ignoring blank lines and
+// # comments. Every ID is checked before any are used, and duplicates are dropped.
+func ReadChannelIDs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error opening channels file")
+	}
+	defer file.Close()
+
+	var ids []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+
+		_, err = ParseSnowflake(id)
+		if err != nil {
+			return nil, fmt.Errorf("Line %v of %v isn't a valid channel ID: %q", line, path, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading channels file")
+	}
+
+	return ids, nil
+}
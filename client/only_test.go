@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOnlyChannelDryRunWithLimit(t *testing.T) {
+	search := searchServer(map[string]int{"1": 100}, 0)
+	defer search.Close()
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"someone"}`)
+		case r.URL.Path == "/channels/1":
+			fmt.Fprint(w, `{"id":"1","type":0,"name":"general"}`)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			search.Config.Handler.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetLimit(50)
+
+	err := c.DeleteFromChannelIDs([]string{"1"})
+	assert.Equal(t, ErrorLimitReached, err)
+	assert.Equal(t, int64(50), c.DeletedCount())
+
+	// Nothing else is listed, just the profile, the channel and two pages of results
+	assert.Equal(t, []string{"/users/@me", "/channels/1", "/channels/1/messages/search", "/channels/1/messages/search"}, paths)
+
+	err = c.DeleteFromChannelIDs([]string{"2"})
+	assert.EqualError(t, err, "No channel with ID 2 that we can read")
+}
+
+func TestReadChannelIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels.txt")
+	err := ioutil.WriteFile(path, []byte("# work\n1\n\n 2 \n1\n"), 0600)
+	assert.Nil(t, err)
+
+	ids, err := ReadChannelIDs(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, ids)
+
+	err = ioutil.WriteFile(path, []byte("1\ngeneral\n"), 0600)
+	assert.Nil(t, err)
+
+	_, err = ReadChannelIDs(path)
+	assert.EqualError(t, err, fmt.Sprintf("Line 2 of %v isn't a valid channel ID: \"general\"", path))
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Strategies for paging through search results
+const (
+	// StrategyOffset pages using the offset, seeking past anything we don't delete
+	StrategyOffset = "offset"
+	// StrategyMaxID pages by lowering max_id to the oldest message seen so far
+	StrategyMaxID = "maxid"
+)
+
+// Deleting messages shifts the search window underneath us, and the search index
+// doesn't always catch up straight away, so pages can overlap with ones we've
+// already seen. pageTracker remembers what we've seen within a single pass over
+// a channel or guild so that we can correct for it.
+// It's local to the pass, so passes over different channels can run concurrently.
+type pageTracker struct {
+	seen map[string]bool
+	last string
+	// Whose messages the search was for, hits by anyone else are never deleted
+	author string
+	// System messages we've tried to delete, which may turn out to be undeletable
+	attempted map[string]bool
+	// Upper bound of the next page when using the maxid strategy
+	cursor int64
+	// Messages deleted so far in this pass, and the last of them, for the per-channel limit
+	deleted     int
+	lastDeleted int64
+	limited     bool
+	// Added to the delay between deletions, e.g. in slowmode channels
+	extraDelay time.Duration
+}
+
+func newPageTracker() *pageTracker {
+	return &pageTracker{
+		seen:      make(map[string]bool),
+		attempted: make(map[string]bool),
+	}
+}
+
+// duplicate reports whether a message has already been seen, marking it as seen if not
+func (p *pageTracker) duplicate(id string) bool {
+	if p.seen[id] {
+		return true
+	}
+	p.seen[id] = true
+	return false
+}
+
+// started reports whether any page with hits has been handled yet
+func (p *pageTracker) started() bool {
+	return p.last != ""
+}
+
+// nothingAuthored reports whether the first page of a pass shows we've never sent
+// anything there, so the usual checks for the end of a pass can be skipped
+func nothingAuthored(results *Messages, pages *pageTracker) bool {
+	return !pages.started() && results.TotalResults == 0 && len(results.ContextMessages) == 0
+}
+
+// repeated reports whether a page contains exactly the same hits as the previous page
+func (p *pageTracker) repeated(messages *Messages) bool {
+	var ids []string
+	for _, ctx := range messages.ContextMessages {
+		for _, msg := range ctx {
+			if msg.Hit {
+				ids = append(ids, msg.ID)
+			}
+		}
+	}
+
+	page := strings.Join(ids, ",")
+	repeated := page != "" && page == p.last
+	p.last = page
+
+	return repeated
+}
+
+func hits(messages *Messages) int {
+	count := 0
+	for _, ctx := range messages.ContextMessages {
+		for _, msg := range ctx {
+			if msg.Hit {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// oldestHit returns the snowflake of the oldest hit on a page, or zero if there isn't one
+func oldestHit(messages *Messages) int64 {
+	var oldest int64
+	for _, ctx := range messages.ContextMessages {
+		for _, msg := range ctx {
+			if !msg.Hit {
+				continue
+			}
+			snowflake, err := strconv.ParseInt(msg.ID, 10, 64)
+			if err != nil {
+				continue
+			}
+			if oldest == 0 || snowflake < oldest {
+				oldest = snowflake
+			}
+		}
+	}
+	return oldest
+}
+
+// pageRepeated waits for the index to catch up when the search hands back a page we've
+// already dealt with, so the next fetch shows what's behind it. If it never does, the
+// page is stepped over rather than fetched forever, at the risk of missing up to a page of
+// messages, so the run can't count as having left the account clean.
+func (c *Client) pageRepeated(results *Messages, seek *int, retries *int) error {
+	const maxRetries = 5
+
+	if *retries < maxRetries {
+		(*retries)++
+		log.Debugf("Search returned the same page twice, retrying in %v (%v/%v)", settleDelay, *retries, maxRetries)
+		return c.sleep(settleDelay)
+	}
+
+	log.Warnf("Search kept returning the same page, seeking ahead")
+	atomic.AddInt64(&c.steppedOver, int64(hits(results)))
+	*seek += hits(results)
+	*retries = 0
+	return nil
+}
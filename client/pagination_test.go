@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testPage(ids ...string) *Messages {
+	var ctx [][]Message
+	for _, id := range ids {
+		ctx = append(ctx, []Message{
+			{ID: "context", Hit: false},
+			{ID: id, Hit: true},
+		})
+	}
+	return &Messages{ContextMessages: ctx}
+}
+
+func TestOverlappingPages(t *testing.T) {
+	p := newPageTracker()
+
+	var fresh []string
+	for _, results := range []*Messages{testPage("1", "2", "3"), testPage("3", "4", "5")} {
+		for _, ctx := range results.ContextMessages {
+			for _, msg := range ctx {
+				if msg.Hit && !p.duplicate(msg.ID) {
+					fresh = append(fresh, msg.ID)
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, fresh)
+}
+
+func TestRepeatedPage(t *testing.T) {
+	p := newPageTracker()
+
+	assert.False(t, p.repeated(testPage("1", "2")))
+	assert.True(t, p.repeated(testPage("1", "2")))
+	assert.False(t, p.repeated(testPage("2", "3")))
+}
+
+func TestHits(t *testing.T) {
+	assert.Equal(t, 3, hits(testPage("1", "2", "3")))
+}
+
+func TestOldestHit(t *testing.T) {
+	assert.Equal(t, int64(20), oldestHit(testPage("30", "20", "25")))
+	assert.Equal(t, int64(0), oldestHit(testPage()))
+}
+
+func TestLaggingIndex(t *testing.T) {
+	var mu sync.Mutex
+	var remaining []string
+	for i := 0; i < 10; i++ {
+		remaining = append(remaining, fmt.Sprint(1000+i))
+	}
+	// The index only shows deletions a couple of searches after they happen
+	index := append([]string{}, remaining...)
+	stale := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == "DELETE" {
+			for i, id := range remaining {
+				if strings.HasSuffix(r.URL.Path, "/"+id) {
+					remaining = append(remaining[:i], remaining[i+1:]...)
+					break
+				}
+			}
+			stale = 2
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if stale > 0 {
+			stale--
+		} else {
+			index = append([]string{}, remaining...)
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		results := Messages{TotalResults: len(index)}
+		for i := offset; i < len(index) && i < offset+4; i++ {
+			results.ContextMessages = append(results.ContextMessages, []Message{{
+				ID:        index[i],
+				Hit:       true,
+				ChannelID: "1",
+				Type:      UserMessage,
+				Author:    Recipient{ID: "me"},
+			}})
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer server.Close()
+
+	settleDelay = time.Millisecond
+	defer func() { settleDelay = 2 * time.Second }()
+
+	c := New("token")
+	c.baseURL = server.URL
+	err := c.DeleteFromChannel(&Me{ID: "me"}, &Channel{ID: "1"})
+	assert.Nil(t, err)
+
+	// Jumping the offset past the stale page would have left the last two behind
+	assert.Empty(t, remaining)
+	assert.Equal(t, int64(10), c.DeletedCount())
+	assert.Equal(t, int64(0), c.steppedOver)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrorPlanDiverged = errors.New("Run diverged from the reviewed plan")
+	ErrorPlanTampered = errors.New("Plan file's hash doesn't match the messages in it")
+	ErrorPlanHash     = errors.New("Plan file's hash doesn't match the reviewed hash")
+)
+
+// ReviewedPlan is every message a dry run would delete, for reviewing before the real run
+type ReviewedPlan struct {
+	Count    int      `json:"count"`
+	Hash     string   `json:"hash"`
+	Messages []Record `json:"messages"`
+}
+
+// planFile collects what a dry run would delete, or holds a real run to a reviewed plan
+type planFile struct {
+	mu       sync.Mutex
+	path     string
+	reviewed map[string]Record // Nil in a dry run, which writes the plan instead
+	deleted  map[string]Record
+}
+
+// planHash hashes the sorted message IDs, so the same set always gets the same hash
+func planHash(messages []Record) string {
+	ids := make([]string, 0, len(messages))
+	for _, rec := range messages {
+		ids = append(ids, rec.ID)
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetPlanFile writes what a dry run would delete to path, or makes a real run delete
+// exactly the messages in the plan at path, so call it after SetDryRun
+// The hash is the one that was reviewed, which the plan must still have. The file can be
+// edited along with the hash inside it, so it's the reviewed hash that ties the run to the review.
+func (c *Client) SetPlanFile(path string, hash string) error {
+	c.plan = &planFile{
+		path:    path,
+		deleted: make(map[string]Record),
+	}
+	if c.dryRun {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "Error reading plan file")
+	}
+
+	var plan ReviewedPlan
+	err = json.Unmarshal(data, &plan)
+	if err != nil {
+		return errors.Wrap(err, "Error parsing plan file")
+	}
+
+	// Editing the list of messages after review has to show up in the hash that was signed off
+	if plan.Count != len(plan.Messages) || planHash(plan.Messages) != plan.Hash {
+		return ErrorPlanTampered
+	}
+	if !strings.EqualFold(plan.Hash, hash) {
+		return errors.Wrapf(ErrorPlanHash, "%v has %v, not %v", path, plan.Hash, hash)
+	}
+	log.Infof("Loaded a plan of %v messages with hash %v from %v", plan.Count, plan.Hash, path)
+
+	c.plan.reviewed = make(map[string]Record, len(plan.Messages))
+	for _, rec := range plan.Messages {
+		c.plan.reviewed[rec.ID] = rec
+	}
+
+	return nil
+}
+
+// planAllows stops a real run before it deletes anything the reviewed plan didn't include
+func (c *Client) planAllows(msg *Message) error {
+	if c.plan == nil || c.plan.reviewed == nil {
+		return nil
+	}
+
+	if _, ok := c.plan.reviewed[msg.ID]; !ok {
+		log.Errorf("Message %v in channel %v isn't in the plan, stopping before deleting it", msg.ID, msg.ChannelID)
+		return errors.Wrapf(ErrorPlanDiverged, "Message %v isn't in %v", msg.ID, c.plan.path)
+	}
+
+	return nil
+}
+
+func (c *Client) recordPlan(msg *Message) {
+	if c.plan == nil {
+		return
+	}
+
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+
+	c.plan.deleted[msg.ID] = Record{msg.ID, msg.ChannelID}
+}
+
+// FinishPlan writes the plan at the end of a dry run, or at the end of a real run checks
+// that every message in the plan was deleted
+func (c *Client) FinishPlan() error {
+	if c.plan == nil {
+		return nil
+	}
+
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+
+	if c.plan.reviewed == nil {
+		return c.writePlan()
+	}
+
+	cmp := Compare(c.plan.reviewed, c.plan.deleted)
+	if len(cmp.Missed) > 0 {
+		for _, id := range cmp.Missed {
+			log.Errorf("Planned message %v in channel %v wasn't deleted", id, c.plan.reviewed[id].ChannelID)
+		}
+		return errors.Wrapf(ErrorPlanDiverged, "%v of %v planned messages weren't deleted", len(cmp.Missed), len(c.plan.reviewed))
+	}
+
+	log.Infof("Deleted exactly the %v messages in %v", len(cmp.Matched), c.plan.path)
+
+	return nil
+}
+
+func (c *Client) writePlan() error {
+	plan := ReviewedPlan{Messages: make([]Record, 0, len(c.plan.deleted))}
+	for _, rec := range c.plan.deleted {
+		plan.Messages = append(plan.Messages, rec)
+	}
+	sort.Slice(plan.Messages, func(i, j int) bool {
+		return plan.Messages[i].ID < plan.Messages[j].ID
+	})
+	plan.Count = len(plan.Messages)
+	plan.Hash = planHash(plan.Messages)
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error encoding plan file")
+	}
+	err = WriteFileAtomic(c.plan.path, append(data, '\n'), 0600)
+	if err != nil {
+		return errors.Wrap(err, "Error writing plan file")
+	}
+
+	log.Infof("Wrote a plan of %v messages with hash %v to %v, pass it to a real run with --plan-file and --plan-hash once reviewed", plan.Count, plan.Hash, c.plan.path)
+
+	return nil
+}
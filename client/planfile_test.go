@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	first := &Message{ID: "2", ChannelID: "10"}
+	second := &Message{ID: "1", ChannelID: "20"}
+
+	dry := New("token")
+	dry.SetDryRun(true)
+	assert.Nil(t, dry.SetPlanFile(path, ""))
+	assert.Nil(t, dry.planAllows(first))
+	assert.Nil(t, dry.record(first))
+	assert.Nil(t, dry.record(second))
+	assert.Nil(t, dry.FinishPlan())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	var plan ReviewedPlan
+	assert.Nil(t, json.Unmarshal(data, &plan))
+	assert.Equal(t, 2, plan.Count)
+	assert.Equal(t, []Record{{"1", "20"}, {"2", "10"}}, plan.Messages)
+	assert.Equal(t, planHash([]Record{{"2", "10"}, {"1", "20"}}), plan.Hash)
+
+	c := New("token")
+	assert.Equal(t, ErrorPlanHash, errors.Cause(c.SetPlanFile(path, planHash([]Record{{"1", "20"}}))))
+	assert.Nil(t, c.SetPlanFile(path, plan.Hash))
+	assert.Nil(t, c.planAllows(first))
+	err = c.planAllows(&Message{ID: "3", ChannelID: "10"})
+	assert.Equal(t, ErrorPlanDiverged, errors.Cause(err))
+
+	// Stopping short of the plan is a divergence too
+	assert.Nil(t, c.record(first))
+	assert.Equal(t, ErrorPlanDiverged, errors.Cause(c.FinishPlan()))
+	assert.Nil(t, c.record(second))
+	assert.Nil(t, c.FinishPlan())
+}
+
+func TestPlanFileTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	plan := ReviewedPlan{
+		Count:    2,
+		Hash:     planHash([]Record{{"1", "10"}}),
+		Messages: []Record{{"1", "10"}, {"2", "10"}},
+	}
+	data, err := json.Marshal(plan)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, data, 0600))
+
+	c := New("token")
+	assert.Equal(t, ErrorPlanTampered, c.SetPlanFile(path, plan.Hash))
+
+	// Editing the messages and the hash together only gets past the reviewed hash if it's changed too
+	reviewed := plan.Hash
+	plan.Hash = planHash(plan.Messages)
+	data, err = json.Marshal(plan)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, data, 0600))
+	assert.Equal(t, ErrorPlanHash, errors.Cause(c.SetPlanFile(path, reviewed)))
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// channelProgress counts the messages deleted from each channel, along with the last
+// (i.e. oldest) of them, which is where a resumed run picks the channel up from
+type channelProgress struct {
+	mu      sync.Mutex
+	deleted map[string]int64
+	last    map[string]string
+}
+
+func newChannelProgress() *channelProgress {
+	return &channelProgress{
+		deleted: make(map[string]int64),
+		last:    make(map[string]string),
+	}
+}
+
+func (p *channelProgress) add(msg *Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.deleted[msg.ChannelID]++
+	p.last[msg.ChannelID] = msg.ID
+}
+
+func (p *channelProgress) log() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.deleted) == 0 {
+		return
+	}
+
+	var channels []string
+	for channel := range p.deleted {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return olderID(channels[i], channels[j])
+	})
+
+	log.Infof("Deleted by channel, with the last message deleted from each:")
+	for _, channel := range channels {
+		log.Infof("%-20v %6v  %v", channel, p.deleted[channel], p.last[channel])
+	}
+}
+
+// logProgress reports how far a run got before it ended early, whether it failed or
+// was stopped, so it's clear where a resumed run will carry on from
+func (c *Client) logProgress(err error) {
+	if err == nil || atomic.LoadInt64(&c.passCount) == 0 {
+		return
+	}
+
+	c.logSummary()
+	c.progress.log()
+}
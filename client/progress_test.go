@@ -0,0 +1,48 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProgressReportedOnFailure(t *testing.T) {
+	search := searchServer(map[string]int{"1": 30}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"someone"}`)
+		case r.URL.Path == "/users/@me/channels":
+			fmt.Fprint(w, `[{"id":"1","type":1,"recipients":[{"id":"2"}]}]`)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			search.Config.Handler.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	c.SetSkipRelationships(true)
+	c.SetServerRetries(0)
+
+	err := c.PartialDelete()
+	assert.True(t, serverError(err))
+	assert.Contains(t, out.String(), "Finished deleting messages: 30 deleted")
+	assert.Contains(t, out.String(), "Deleted by channel")
+	assert.Contains(t, out.String(), fmt.Sprintf("%-20v %6v  %v", "1", 30, "10029"))
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	// Deletions older than this no longer count towards the current rate
+	rateWindow = 30 * time.Second
+	// How often the current rate is logged
+	rateInterval = 10 * time.Second
+)
+
+// deletionRate keeps the times of recent deletions, to report how quickly we're
+// deleting right now rather than averaged over the whole run
+type deletionRate struct {
+	mu      sync.Mutex
+	times   []time.Time
+	lastLog time.Time
+}
+
+func newDeletionRate() *deletionRate {
+	return &deletionRate{
+		lastLog: time.Now(),
+	}
+}
+
+// add records a deletion, logging the current rate if it's been a while
+func (r *deletionRate) add(now time.Time, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times = append(r.times, now)
+
+	if now.Sub(r.lastLog) >= rateInterval {
+		r.lastLog = now
+		log.Infof("Deleting %.1f messages/sec over the last %v, %v deleted so far", r.rate(now), rateWindow, total)
+	}
+}
+
+// rate must be called with the lock held
+func (r *deletionRate) rate(now time.Time) float64 {
+	// Drop anything that's fallen out of the window
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(r.times) && r.times[i].Before(cutoff) {
+		i++
+	}
+	r.times = r.times[i:]
+
+	return float64(len(r.times)) / rateWindow.Seconds()
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDeletionRate(t *testing.T) {
+	start := time.Now()
+	r := newDeletionRate()
+
+	// 30 deletions a second apart, then 15 more in the following 15 seconds at half the pace
+	for i := 0; i < 30; i++ {
+		r.add(start.Add(time.Duration(i)*time.Second), int64(i))
+	}
+	assert.InDelta(t, 1.0, r.rate(start.Add(29*time.Second)), 0.01)
+
+	for i := 0; i < 15; i++ {
+		r.add(start.Add(30*time.Second+time.Duration(i*2)*time.Second), int64(30+i))
+	}
+	assert.InDelta(t, 0.5, r.rate(start.Add(59*time.Second)), 0.05)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Discord rate limits per route, and also globally across every route
+//
+// Route limits only hold up the caller that hit them: the request sleeps and is retried
+// in place, so when channels are processed concurrently, a channel that's being rate
+// limited backs off on its own while every other channel carries on.
+//
+// The global limit applies to the whole account, so when we hit it, rateLimiter holds
+// back every request until it's lifted, regardless of which channel it's for.
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{}
+}
+
+// pause holds back every request for the given duration
+func (r *rateLimiter) pause(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(r.until) {
+		r.until = until
+	}
+}
+
+// wait blocks until the global rate limit, if any, has been lifted or ctx is cancelled
+func (r *rateLimiter) wait(ctx context.Context) error {
+	r.mu.Lock()
+	remaining := time.Until(r.until)
+	r.mu.Unlock()
+
+	if remaining <= 0 {
+		return nil
+	}
+
+	log.Debugf("Waiting %v for the global rate limit to lift", remaining)
+	return sleep(ctx, remaining)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPause(t *testing.T) {
+	r := newRateLimiter()
+	r.pause(50 * time.Millisecond)
+
+	start := time.Now()
+	assert.Nil(t, r.wait(context.Background()))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestInterruptedWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	r := newRateLimiter()
+	r.pause(time.Hour)
+
+	start := time.Now()
+	assert.Equal(t, ErrorInterrupted, r.wait(ctx))
+	assert.True(t, time.Since(start) < time.Minute)
+
+	// Once cancelled, any further sleep gives up at once
+	c := New("token")
+	c.SetContext(ctx)
+	assert.Equal(t, ErrorInterrupted, c.sleep(time.Hour))
+	assert.Equal(t, ErrorInterrupted, c.betweenPages())
+}
+
+func TestRateLimiterKeepsLongestPause(t *testing.T) {
+	r := newRateLimiter()
+	r.pause(time.Hour)
+	r.pause(time.Millisecond)
+
+	assert.True(t, time.Until(r.until) > time.Minute)
+}
+
+func TestResetWait(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	// Only retry_after in the body, so there's nothing to go on here
+	_, ok := resetWait(http.Header{}, now)
+	assert.False(t, ok)
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Reset", "1600000002.500")
+	wait, ok := resetWait(header, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2500*time.Millisecond, wait)
+
+	// Our clock is an hour ahead of the server's, which would make the reset look long past
+	skewed := time.Unix(1600003600, 0)
+	header.Set("Date", now.UTC().Format(http.TimeFormat))
+	wait, ok = resetWait(header, skewed)
+	assert.True(t, ok)
+	assert.Equal(t, 2500*time.Millisecond, wait)
+
+	// A reset in the past falls back to retry_after
+	header.Set("X-RateLimit-Reset", "1599999999")
+	_, ok = resetWait(header, now)
+	assert.False(t, ok)
+
+	// The relative reset doesn't depend on anyone's clock, so it wins
+	header.Set("X-RateLimit-Reset-After", "1.250")
+	wait, ok = resetWait(header, skewed)
+	assert.True(t, ok)
+	assert.Equal(t, 1250*time.Millisecond, wait)
+}
+
+func TestWaitIgnoresResetOutsideRouteLimits(t *testing.T) {
+	c := New("token")
+
+	// A 202 while the index builds asks for its own wait, whatever the route's bucket says
+	res := &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header:     http.Header{"X-Ratelimit-Reset-After": {"3600"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"retry_after":5}`)),
+	}
+	start := time.Now()
+	assert.Nil(t, c.wait(res, 1))
+	assert.True(t, time.Since(start) < time.Second)
+
+	// As does a global limit, which holds back every request rather than sleeping here
+	res = &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"X-Ratelimit-Reset-After": {"3600"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"retry_after":0.005,"global":true}`)),
+	}
+	assert.Nil(t, c.wait(res, 1000))
+	assert.True(t, time.Until(c.global.until) < time.Second)
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// Filters on whether we've read a channel up to its latest message
+const (
+	// ReadOnly deletes only from channels we've read, e.g. conversations that have ended
+	ReadOnly = "read"
+	// UnreadOnly deletes only from channels with messages we haven't read
+	UnreadOnly = "unread"
+)
+
+var ErrorInvalidReadState = errors.New("Unknown read state filter, expected read or unread")
+
+// ReadState is how far we've read a channel, as the Discord client records it
+type ReadState struct {
+	ID            string `json:"id"`
+	LastMessageID string `json:"last_message_id"`
+	MentionCount  int    `json:"mention_count"`
+}
+
+// readStates maps each channel to whether it's been read, looking up a channel's
+// latest message the first time its read state is needed
+type readStates struct {
+	mu     sync.Mutex
+	states map[string]ReadState
+	read   map[string]bool
+}
+
+// SetReadStateFilter deletes only from channels we've read, or only from unread ones
+// Channels whose read state can't be worked out are skipped either way
+func (c *Client) SetReadStateFilter(filter string) error {
+	switch filter {
+	case "", ReadOnly, UnreadOnly:
+		c.readFilter = filter
+		return nil
+	default:
+		return ErrorInvalidReadState
+	}
+}
+
+// ReadStates fetches how far each channel has been read
+// This isn't part of Discord's documented API, so it may not be available
+func (c *Client) ReadStates() ([]ReadState, error) {
+	endpoint := endpoints["read_states"]
+	var states []ReadState
+	err := c.strictRequest("GET", endpoint, nil, &states)
+	if err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
+// loadReadStates fetches the read states once at the start of a run, carrying on
+// without them if they're unavailable, in which case every channel is skipped
+func (c *Client) loadReadStates() {
+	if c.readFilter == "" {
+		return
+	}
+
+	c.readStates = &readStates{
+		states: make(map[string]ReadState),
+		read:   make(map[string]bool),
+	}
+
+	states, err := c.ReadStates()
+	if err != nil {
+		log.Warnf("Read states are unavailable, so no channel can be told to be %v and all will be skipped: %v", c.readFilter, err)
+		return
+	}
+	for _, state := range states {
+		c.readStates.states[state.ID] = state
+	}
+	log.Infof("Fetched read states for %v channels", len(states))
+}
+
+// channelRead reports whether we've read the channel up to its latest message
+// ok is false when it can't be worked out, e.g. the channel has no read state
+func (c *Client) channelRead(channelID string) (read bool, ok bool) {
+	c.readStates.mu.Lock()
+	defer c.readStates.mu.Unlock()
+
+	if read, ok := c.readStates.read[channelID]; ok {
+		return read, true
+	}
+
+	state, ok := c.readStates.states[channelID]
+	if !ok {
+		return false, false
+	}
+
+	channel, err := c.Channel(channelID)
+	if err != nil {
+		log.Warnf("Couldn't look up the latest message in channel %v, skipping it: %v", channelID, err)
+		return false, false
+	}
+
+	read = state.MentionCount == 0 && !olderID(state.LastMessageID, channel.LastMessageID)
+	c.readStates.read[channelID] = read
+	return read, true
+}
+
+// readStateMatches reports whether a channel should be deleted from under the read state filter
+func (c *Client) readStateMatches(channelID string) bool {
+	if c.readFilter == "" {
+		return true
+	}
+
+	read, ok := c.channelRead(channelID)
+	if !ok {
+		log.Debugf("Read state of channel %v is unknown, skipping", channelID)
+		return false
+	}
+
+	return read == (c.readFilter == ReadOnly)
+}
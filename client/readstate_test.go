@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func readStateServer(available bool) *httptest.Server {
+	search := searchServer(map[string]int{"1": 30, "2": 10, "3": 5}, 0)
+	last := map[string]string{"1": "10029", "2": "10010", "3": "10004"}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"someone"}`)
+		case r.URL.Path == "/users/@me/read-states":
+			if !available {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// Channel 1 is read to the end, channel 2 isn't, and channel 3 has no read state
+			fmt.Fprint(w, `[{"id":"1","last_message_id":"10029"},{"id":"2","last_message_id":"10000"}]`)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			search.Config.Handler.ServeHTTP(w, r)
+		case strings.HasPrefix(r.URL.Path, "/channels/"):
+			id := strings.TrimPrefix(r.URL.Path, "/channels/")
+			fmt.Fprintf(w, `{"id":"%v","type":1,"last_message_id":"%v"}`, id, last[id])
+		}
+	}))
+}
+
+func TestReadStateFilter(t *testing.T) {
+	server := readStateServer(true)
+	defer server.Close()
+
+	for filter, deleted := range map[string]int64{ReadOnly: 30, UnreadOnly: 10} {
+		c := New("token")
+		c.baseURL = server.URL
+		c.SetDryRun(true)
+		assert.Nil(t, c.SetReadStateFilter(filter))
+
+		err := c.DeleteFromChannelIDs([]string{"1", "2", "3"})
+		assert.Nil(t, err)
+		assert.Equal(t, deleted, c.DeletedCount(), filter)
+	}
+
+	c := New("token")
+	assert.Equal(t, ErrorInvalidReadState, c.SetReadStateFilter("seen"))
+}
+
+func TestReadStatesUnavailable(t *testing.T) {
+	server := readStateServer(false)
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	assert.Nil(t, c.SetReadStateFilter(ReadOnly))
+
+	err := c.DeleteFromChannelIDs([]string{"1", "2", "3"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), c.DeletedCount())
+}
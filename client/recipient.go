@@ -0,0 +1,134 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+// String formats the recipient the same way Discord displays them
+func (r *Recipient) String() string {
+	if r.Discriminator == "" || r.Discriminator == "0" {
+		return r.Username
+	}
+	return fmt.Sprintf("%v#%v", r.Username, r.Discriminator)
+}
+
+// String names the account the same way as other users, e.g. in whoami
+func (m *Me) String() string {
+	return (&Recipient{Username: m.Username, Discriminator: m.Discriminator}).String()
+}
+
+// matchRecipients finds the recipients matching a user ID, username#discriminator or bare username
+func matchRecipients(query string, candidates []Recipient) []Recipient {
+	var matches []Recipient
+
+	for _, candidate := range candidates {
+		switch {
+		case candidate.ID == query:
+		case strings.Contains(query, "#") && strings.EqualFold(candidate.String(), query):
+		case !strings.Contains(query, "#") && strings.EqualFold(candidate.Username, query):
+		default:
+			continue
+		}
+		matches = append(matches, candidate)
+	}
+
+	return matches
+}
+
+// DeleteFromRecipients deletes messages only from the DMs with the given recipients,
+// which may each be a user ID, username#discriminator or username
+func (c *Client) DeleteFromRecipients(queries []string) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	err = c.writeManifest()
+	if err != nil {
+		return err
+	}
+
+	channels, err := c.Channels()
+	if err != nil {
+		return errors.Wrap(err, "Error fetching channels")
+	}
+
+	relationships, err := c.Relationships()
+	if err != nil && !hasStatus(err, http.StatusForbidden) {
+		return errors.Wrap(err, "Error fetching relationships")
+	}
+
+	// Every user we could have a DM with, without duplicates
+	var candidates []Recipient
+	known := make(map[string]bool)
+	addCandidate := func(r Recipient) {
+		if !known[r.ID] {
+			known[r.ID] = true
+			candidates = append(candidates, r)
+		}
+	}
+	for _, channel := range channels {
+		if channel.Type == DirectChannel && len(channel.Recipients) == 1 {
+			addCandidate(channel.Recipients[0])
+		}
+	}
+	for _, relation := range relationships {
+		addCandidate(relation.Recipient)
+	}
+
+	// Resolve everything up front so that an ambiguous recipient doesn't leave us half done
+	var recipients []Recipient
+	for _, query := range queries {
+		matches := matchRecipients(query, candidates)
+
+		switch len(matches) {
+		case 0:
+			return fmt.Errorf("No DM recipient found matching '%v'", query)
+		case 1:
+			recipients = append(recipients, matches[0])
+		default:
+			var names []string
+			for _, match := range matches {
+				names = append(names, fmt.Sprintf("%v (%v)", match.String(), match.ID))
+			}
+			return fmt.Errorf("Recipient '%v' is ambiguous, it could be any of: %v", query, strings.Join(names, ", "))
+		}
+	}
+
+Recipients:
+	for _, recipient := range recipients {
+		for _, channel := range channels {
+			if channel.Type == DirectChannel && len(channel.Recipients) == 1 && channel.Recipients[0].ID == recipient.ID {
+				log.Infof("Deleting messages sent to '%v' in channel %v", recipient.String(), channel.ID)
+				err = c.DeleteFromChannel(me, &channel)
+				if err != nil {
+					return err
+				}
+				continue Recipients
+			}
+		}
+
+		if c.noReopenDMs {
+			return fmt.Errorf("DM with '%v' isn't open, and reopening it isn't allowed", recipient.String())
+		}
+
+		channel, err := c.ChannelRelationship(&recipient)
+		if err != nil {
+			return errors.Wrap(err, "Error resolving recipient to channel")
+		}
+
+		log.Infof("Deleting messages sent to '%v' in channel %v", recipient.String(), channel.ID)
+		err = c.DeleteFromChannel(me, channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logSummary()
+
+	return nil
+}
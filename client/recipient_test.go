@@ -0,0 +1,37 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testRecipients = []Recipient{
+	{Username: "alice", Discriminator: "1234", ID: "1"},
+	{Username: "alice", Discriminator: "5678", ID: "2"},
+	{Username: "bob", Discriminator: "0", ID: "3"},
+}
+
+func TestMatchRecipientByID(t *testing.T) {
+	matches := matchRecipients("2", testRecipients)
+	assert.Equal(t, []Recipient{testRecipients[1]}, matches)
+}
+
+func TestMatchRecipientByTag(t *testing.T) {
+	matches := matchRecipients("Alice#5678", testRecipients)
+	assert.Equal(t, []Recipient{testRecipients[1]}, matches)
+}
+
+func TestMatchRecipientByUsername(t *testing.T) {
+	matches := matchRecipients("bob", testRecipients)
+	assert.Equal(t, []Recipient{testRecipients[2]}, matches)
+}
+
+func TestMatchRecipientAmbiguous(t *testing.T) {
+	matches := matchRecipients("alice", testRecipients)
+	assert.Len(t, matches, 2)
+}
+
+func TestMeString(t *testing.T) {
+	assert.Equal(t, "alice#1234", (&Me{Username: "alice", Discriminator: "1234"}).String())
+	assert.Equal(t, "bob", (&Me{Username: "bob", Discriminator: "0"}).String())
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrorInvalidRelationshipType = errors.New("Unknown relationship type, expected 1 (friend), 2 (blocked), 3 (incoming request) or 4 (outgoing request)")
+
+// Relationship types, as returned by /users/@me/relationships
+const (
+	RelationshipFriend   = 1
+	RelationshipBlocked  = 2
+	RelationshipIncoming = 3
+	RelationshipOutgoing = 4
+)
+
+// SetRelationshipTypes limits the relationships phase to relationships of the given
+// types, e.g. only blocked users. Empty processes every relationship.
+func (c *Client) SetRelationshipTypes(types []int) error {
+	wanted := make(map[int]bool)
+	for _, kind := range types {
+		if kind < RelationshipFriend || kind > RelationshipOutgoing {
+			return errors.Wrapf(ErrorInvalidRelationshipType, "%v", kind)
+		}
+		wanted[kind] = true
+	}
+
+	if len(wanted) == 0 {
+		wanted = nil
+	}
+	c.relationshipTypes = wanted
+	return nil
+}
+
+// relationWanted reports whether the relationship is one of the types we were asked for
+func (c *Client) relationWanted(relation Relationship) bool {
+	if c.relationshipTypes == nil || c.relationshipTypes[relation.Type] {
+		return true
+	}
+
+	log.Debugf("Skipping relationship with '%v' of type %v", relation.Recipient.Username, relation.Type)
+	return false
+}
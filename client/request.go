@@ -5,7 +5,13 @@ import (
 )
 
 func (c *Client) DeleteMessage(msg *Message) error {
+	err := c.acquireDelete()
+	if err != nil {
+		return err
+	}
+	defer c.releaseDelete()
+
 	endpoint := fmt.Sprintf(endpoints["delete_msg"], msg.ChannelID, msg.ID)
-	err := c.request("DELETE", endpoint, nil, nil)
+	err = c.strictRequest("DELETE", endpoint, nil, nil)
 	return err
 }
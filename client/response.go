@@ -2,8 +2,15 @@ package client
 
 import (
 	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync/atomic"
 )
 
+// searchDecodeRetries is how many times a search page that came back malformed is
+// requested again before giving up
+const searchDecodeRetries = 3
+
 func (c *Client) Me() (*Me, error) {
 	endpoint := endpoints["me"]
 	var me Me
@@ -12,6 +19,11 @@ func (c *Client) Me() (*Me, error) {
 		return nil, err
 	}
 
+	err = c.expectMe(&me)
+	if err != nil {
+		return nil, err
+	}
+
 	return &me, nil
 }
 
@@ -23,10 +35,15 @@ func (c *Client) Channels() ([]Channel, error) {
 		return nil, err
 	}
 
+	err = c.expectChannels("channel", channels)
+	if err != nil {
+		return nil, err
+	}
+
 	return channels, nil
 }
 
-func (c *Client) ChannelMessages(channel *Channel, me *Me, seek *int) (*Messages, error) {
+func (c *Client) ChannelMessages(channel *Channel, me *Me, seek *int, cursor int64) (*Messages, error) {
 	endpoint := fmt.Sprintf(
 		endpoints["channel_msgs"],
 		channel.ID,
@@ -35,16 +52,11 @@ func (c *Client) ChannelMessages(channel *Channel, me *Me, seek *int) (*Messages
 		messageLimit,
 	)
 
-	if c.minID > 0 {
-		endpoint = fmt.Sprintf("%v&min_id=%v", endpoint, c.minID)
-	}
-
-	if c.maxID > 0 {
-		endpoint = fmt.Sprintf("%v&max_id=%v", endpoint, c.maxID)
-	}
+	endpoint = c.withBounds(endpoint, cursor)
 
 	var results Messages
-	err := c.request("GET", endpoint, nil, &results)
+	// A 403 is handled by the caller, rather than looking like a channel with nothing in it
+	err := c.searchRequest(endpoint, &results)
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +64,34 @@ func (c *Client) ChannelMessages(channel *Channel, me *Me, seek *int) (*Messages
 	return &results, nil
 }
 
+// searchRequest fetches a page of search results, requesting it again if the body
+// was truncated or otherwise malformed. Searches are safe to repeat, unlike deletes.
+func (c *Client) searchRequest(endpoint string, results *Messages) error {
+	for attempt := 1; ; attempt++ {
+		// Don't let a partially decoded page leak into the retry
+		*results = Messages{}
+
+		err := c.strictRequest("GET", endpoint, nil, results)
+		if err == nil {
+			if results.unrecognised {
+				// The page is skipped rather than failing, but the account can't be called clean
+				atomic.AddInt64(&c.unreadPages, 1)
+			}
+			return c.expectMessages(results)
+		}
+		if _, ok := errors.Cause(err).(*DecodeError); !ok || attempt > searchDecodeRetries {
+			return err
+		}
+
+		log.Warnf("%v for %v, requesting the page again in %v (%v/%v)", err, endpoint, settleDelay, attempt, searchDecodeRetries)
+		// Give whatever cut the body short a moment, rather than asking again straight away
+		err = c.sleep(settleDelay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (c *Client) ChannelRelationship(relation *Recipient) (*Channel, error) {
 	endpoint := endpoints["channels"]
 	recipients := struct {
@@ -71,7 +111,13 @@ func (c *Client) ChannelRelationship(relation *Recipient) (*Channel, error) {
 func (c *Client) Relationships() ([]Relationship, error) {
 	endpoint := endpoints["relationships"]
 	var relations []Relationship
-	err := c.request("GET", endpoint, nil, &relations)
+	// Strict, so that a run which couldn't list them knows it missed closed DMs
+	err := c.strictRequest("GET", endpoint, nil, &relations)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.expectRelationships(relations)
 	if err != nil {
 		return nil, err
 	}
@@ -87,10 +133,31 @@ func (c *Client) Guilds() ([]Channel, error) {
 		return nil, err
 	}
 
+	err = c.expectChannels("guild", channels)
+	if err != nil {
+		return nil, err
+	}
+
 	return channels, nil
 }
 
-func (c *Client) GuildMessages(channel *Channel, me *Me, seek *int) (*Messages, error) {
+func (c *Client) GuildChannels(guild *Channel) ([]Channel, error) {
+	endpoint := fmt.Sprintf(endpoints["guild_channels"], guild.ID)
+	var channels []Channel
+	err := c.request("GET", endpoint, nil, &channels)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.expectChannels("guild channel", channels)
+	if err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+func (c *Client) GuildMessages(channel *Channel, me *Me, seek *int, cursor int64) (*Messages, error) {
 	endpoint := fmt.Sprintf(
 		endpoints["guild_msgs"],
 		channel.ID,
@@ -99,20 +166,65 @@ func (c *Client) GuildMessages(channel *Channel, me *Me, seek *int) (*Messages,
 		messageLimit,
 	)
 
-	if c.minID > 0 {
-		endpoint = fmt.Sprintf("%v&min_id=%v", endpoint, c.minID)
-	}
+	endpoint = c.withBounds(endpoint, cursor)
 
-	if c.maxID > 0 {
-		endpoint = fmt.Sprintf("%v&max_id=%v", endpoint, c.maxID)
+	var results Messages
+
+	// Guilds we've left start returning 403, which the caller needs to know about
+	err := c.searchRequest(endpoint, &results)
+	if err != nil {
+		return nil, err
 	}
 
-	var results Messages
+	return &results, nil
+}
 
+// newestMessage peeks at the most recent message we've sent in a channel or guild,
+// ignoring any bounds, using the given search endpoint
+func (c *Client) newestMessage(search string, channel *Channel, me *Me) (*Message, error) {
+	endpoint := fmt.Sprintf(endpoints[search], channel.ID, me.ID, 0, 1)
+
+	var results Messages
 	err := c.request("GET", endpoint, nil, &results)
 	if err != nil {
 		return nil, err
 	}
 
-	return &results, nil
+	for _, ctx := range results.ContextMessages {
+		for _, msg := range ctx {
+			if msg.Hit {
+				return &msg, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// withBounds appends the snowflake bounds, along with any filters the search supports,
+// to a search endpoint so that the server
+// filters out messages we aren't interested in, rather than returning them to us
+// The cursor is used by the maxid strategy to narrow the upper bound as we page
+func (c *Client) withBounds(endpoint string, cursor int64) string {
+	if c.repliesTo != "" {
+		endpoint = fmt.Sprintf("%v&mentions=%v", endpoint, c.repliesTo)
+	}
+	if c.linksOnly {
+		endpoint = fmt.Sprintf("%v&has=link", endpoint)
+	}
+
+	if c.minID > 0 {
+		endpoint = fmt.Sprintf("%v&min_id=%v", endpoint, c.minID)
+	}
+
+	maxID := c.maxID
+	if cursor > 0 && (maxID == 0 || cursor < maxID) {
+		maxID = cursor
+	}
+
+	if maxID > 0 {
+		endpoint = fmt.Sprintf("%v&max_id=%v", endpoint, maxID)
+	}
+
+	return endpoint
 }
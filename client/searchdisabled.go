@@ -0,0 +1,54 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Discord's error code for a feature that's been switched off, which is how a guild with
+// search disabled answers, as opposed to a search we aren't allowed to make
+const codeFeatureDisabled = 40006
+
+// searchDisabled reports whether a search failed because search is switched off, going
+// by the error code or, failing that, the message Discord gave
+func searchDisabled(err error) bool {
+	statusErr, ok := errors.Cause(err).(*StatusError)
+	if !ok {
+		return false
+	}
+
+	message := strings.ToLower(statusErr.Message)
+	return statusErr.Code == codeFeatureDisabled || (strings.Contains(message, "search") && strings.Contains(message, "disabled"))
+}
+
+// searchDisabledFallback searches each channel in a guild on its own when the guild wide
+// search is disabled, which sometimes still works, and skips the guild when it doesn't
+func (c *Client) searchDisabledFallback(me *Me, guild *Channel) error {
+	log.Warnf("Search is disabled for guild '%v', searching its channels one at a time instead", guild.Name)
+
+	err := c.scanGuildChannels(me, guild)
+	if searchDisabled(err) {
+		log.Warnf("Search is disabled for every channel in guild '%v' too, skipping", guild.Name)
+		c.unsearchableGuilds = append(c.unsearchableGuilds, guild.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.fallbackGuilds = append(c.fallbackGuilds, guild.Name)
+	return nil
+}
+
+func (c *Client) logSearchDisabled() {
+	if len(c.fallbackGuilds) > 0 {
+		log.Infof("Searched %v guilds channel by channel because their search is disabled: %v", len(c.fallbackGuilds), strings.Join(c.fallbackGuilds, ", "))
+	}
+	if len(c.unsearchableGuilds) > 0 {
+		log.Warnf("Skipped %v guilds where search is disabled: %v", len(c.unsearchableGuilds), strings.Join(c.unsearchableGuilds, ", "))
+	}
+	if disabled := c.disabledChannels.list(); len(disabled) > 0 {
+		log.Warnf("Skipped %v channels where search is disabled: %v", len(disabled), strings.Join(disabled, ", "))
+	}
+}
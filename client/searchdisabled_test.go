@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSearchDisabled(t *testing.T) {
+	search := searchServer(map[string]int{"2": 5}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/guilds/") && strings.HasSuffix(r.URL.Path, "/messages/search"),
+			r.URL.Path == "/channels/9/messages/search", r.URL.Path == "/channels/3/messages/search":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"code":40006,"message":"This feature has been temporarily disabled server-side"}`)
+		case r.URL.Path == "/guilds/1/channels":
+			fmt.Fprint(w, `[{"id":"4","type":4,"name":"text"},{"id":"3","type":0,"name":"off-topic"},{"id":"2","type":0,"name":"general"}]`)
+		case r.URL.Path == "/guilds/8/channels":
+			fmt.Fprint(w, `[{"id":"9","type":0,"name":"general"}]`)
+		default:
+			search.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+	me := &Me{ID: "me"}
+
+	// Channel searches still work, so the guild falls back to them, carrying on past the
+	// one channel that's disabled as well
+	assert.Nil(t, c.DeleteFromGuild(me, &Channel{ID: "1", Name: "fallback"}))
+	assert.Equal(t, int64(5), c.DeletedCount())
+	assert.Equal(t, []string{"fallback"}, c.fallbackGuilds)
+	assert.Equal(t, []string{"3"}, c.disabledChannels.list())
+	assert.Empty(t, c.unsearchableGuilds)
+
+	// Channel searches are disabled too, so the guild is skipped rather than failing the run
+	assert.Nil(t, c.DeleteFromGuild(me, &Channel{ID: "8", Name: "disabled"}))
+	assert.Equal(t, []string{"disabled"}, c.unsearchableGuilds)
+	assert.Empty(t, c.inaccessibleGuilds)
+	assert.Empty(t, c.forbiddenChannels.list())
+}
+
+func TestStatusErrorEnvelope(t *testing.T) {
+	err := &StatusError{http.StatusForbidden, 50001, "Missing Access"}
+	assert.EqualError(t, err, "Bad status code Forbidden: Missing Access (50001)")
+	assert.False(t, searchDisabled(err))
+	assert.True(t, searchDisabled(&StatusError{http.StatusBadRequest, 0, "Search is disabled for this guild"}))
+}
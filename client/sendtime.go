@@ -0,0 +1,119 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorInvalidHours = errors.New("Hours must look like 2-5 or 22, from 0 to 24")
+	ErrorInvalidDays  = errors.New("Unknown day, expected mon, tue, wed, thu, fri, sat or sun")
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// SetHours only deletes messages sent within the given hours of the day, as a comma
+// separated list of ranges like 2-5 (from 2:00 up to 5:00) or single hours like 22
+// Ranges can wrap past midnight, e.g. 22-3
+func (c *Client) SetHours(hours string) error {
+	c.hours = nil
+	if hours == "" {
+		return nil
+	}
+
+	c.hours = make(map[int]bool)
+	for _, part := range strings.Split(hours, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil || start < 0 || start > 23 {
+			return ErrorInvalidHours
+		}
+		end := start + 1
+		if len(bounds) == 2 {
+			end, err = strconv.Atoi(bounds[1])
+			if err != nil || end < 0 || end > 24 || end%24 == start {
+				return ErrorInvalidHours
+			}
+		}
+
+		for hour := start; hour != end%24; hour = (hour + 1) % 24 {
+			c.hours[hour] = true
+		}
+	}
+
+	return nil
+}
+
+// SetDays only deletes messages sent on the given days of the week, e.g. sat and sun
+func (c *Client) SetDays(days []string) error {
+	c.days = nil
+	if len(days) == 0 {
+		return nil
+	}
+
+	c.days = make(map[time.Weekday]bool)
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		// Full names are fine too
+		if len(day) > 3 && strings.HasPrefix(strings.ToLower(weekdays[day[:3]].String()), day) {
+			day = day[:3]
+		}
+
+		weekday, ok := weekdays[day]
+		if !ok {
+			return ErrorInvalidDays
+		}
+		c.days[weekday] = true
+	}
+
+	return nil
+}
+
+// SetTimezone sets the timezone that --hours and --days are in, either local (the
+// default), UTC or a name like Europe/London
+func (c *Client) SetTimezone(name string) error {
+	if name == "" || strings.EqualFold(name, "local") {
+		c.location = time.Local
+		return nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return errors.Wrap(err, "Error loading timezone")
+	}
+	c.location = location
+
+	return nil
+}
+
+// sentMatches reports whether a message was sent within the hours and days we've been given,
+// going by the time in its ID
+func (c *Client) sentMatches(msg *Message) bool {
+	if c.hours == nil && c.days == nil {
+		return true
+	}
+
+	sent, err := sentAt(msg.ID)
+	if err != nil {
+		return false
+	}
+
+	location := c.location
+	if location == nil {
+		location = time.Local
+	}
+	sent = sent.In(location)
+
+	return (c.hours == nil || c.hours[sent.Hour()]) && (c.days == nil || c.days[sent.Weekday()])
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sentMessage makes a message whose ID decodes to the given time
+func sentMessage(sent time.Time) *Message {
+	ms := sent.UnixNano() / int64(time.Millisecond)
+	return &Message{ID: strconv.FormatInt(toSnowflake(ms), 10)}
+}
+
+func TestSetHours(t *testing.T) {
+	c := New("token")
+
+	assert.Nil(t, c.SetHours("2-5"))
+	assert.Equal(t, map[int]bool{2: true, 3: true, 4: true}, c.hours)
+
+	assert.Nil(t, c.SetHours("22-2,13"))
+	assert.Equal(t, map[int]bool{22: true, 23: true, 0: true, 1: true, 13: true}, c.hours)
+
+	assert.Nil(t, c.SetHours("20-24"))
+	assert.Equal(t, map[int]bool{20: true, 21: true, 22: true, 23: true}, c.hours)
+
+	for _, hours := range []string{"5-5", "24", "-1", "2-25", "two-five", "2-"} {
+		assert.Equal(t, ErrorInvalidHours, c.SetHours(hours), hours)
+	}
+}
+
+func TestSetDays(t *testing.T) {
+	c := New("token")
+
+	assert.Nil(t, c.SetDays([]string{"sat", "Sunday"}))
+	assert.Equal(t, map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}, c.days)
+
+	assert.Equal(t, ErrorInvalidDays, c.SetDays([]string{"sundae"}))
+	assert.Equal(t, ErrorInvalidDays, c.SetDays([]string{"weekend"}))
+}
+
+func TestSentMatches(t *testing.T) {
+	c := New("token")
+	assert.Nil(t, c.SetTimezone("UTC"))
+	assert.Nil(t, c.SetHours("2-5"))
+	assert.Nil(t, c.SetDays([]string{"sat", "sun"}))
+
+	// 2021-01-02 was a Saturday
+	assert.True(t, c.sentMatches(sentMessage(time.Date(2021, 1, 2, 3, 30, 0, 0, time.UTC))))
+	assert.False(t, c.sentMatches(sentMessage(time.Date(2021, 1, 2, 5, 0, 0, 0, time.UTC))))
+	assert.False(t, c.sentMatches(sentMessage(time.Date(2021, 1, 4, 3, 30, 0, 0, time.UTC))))
+
+	// The same moment is 10:30 in Tokyo
+	assert.Nil(t, c.SetTimezone("Asia/Tokyo"))
+	assert.False(t, c.sentMatches(sentMessage(time.Date(2021, 1, 2, 3, 30, 0, 0, time.UTC))))
+	assert.True(t, c.sentMatches(sentMessage(time.Date(2021, 1, 1, 18, 30, 0, 0, time.UTC))))
+
+	assert.NotNil(t, c.SetTimezone("Nowhere/Special"))
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// SetSlowmodeDelay adds delay between deletions in channels with slowmode enabled
+// Slowmode doesn't apply to deleting, so this is only a precaution. It's best-effort,
+// since slowmode is only known for channels searched one at a time, i.e. guild channels
+// with --per-channel-guild-scan (DMs never have it).
+func (c *Client) SetSlowmodeDelay(delay time.Duration) {
+	c.slowmodeDelay = delay
+}
+
+// slowmode logs a channel's slowmode, returning the extra delay to use between deletions in it
+func (c *Client) slowmode(channel *Channel) time.Duration {
+	if channel.RateLimitPerUser <= 0 {
+		return 0
+	}
+
+	log.Debugf("Channel %v has slowmode of %vs", channel.ID, channel.RateLimitPerUser)
+	return c.slowmodeDelay
+}
@@ -0,0 +1,15 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSlowmode(t *testing.T) {
+	c := New("token")
+	c.SetSlowmodeDelay(time.Second)
+
+	assert.Equal(t, time.Second, c.slowmode(&Channel{ID: "1", RateLimitPerUser: 30}))
+	assert.Equal(t, time.Duration(0), c.slowmode(&Channel{ID: "2"}))
+}
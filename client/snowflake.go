@@ -1,5 +1,16 @@
 package client
 
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrorInvalidSnowflake = errors.New("Message ID doesn't seem valid")
+)
+
 const discordEpoch = 1420070400000
 
 func toSnowflake(millis int64) int64 {
@@ -9,3 +20,36 @@ func toSnowflake(millis int64) int64 {
 func fromSnowflake(snowflake int64) int64 {
 	return (snowflake >> 22) + discordEpoch
 }
+
+// ParseSnowflake parses a message ID, rejecting anything that couldn't have been
+// created by Discord
+func ParseSnowflake(id string) (int64, error) {
+	snowflake, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || snowflake <= 0 {
+		return 0, ErrorInvalidSnowflake
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if fromSnowflake(snowflake) > now {
+		return 0, ErrorInvalidSnowflake
+	}
+
+	return snowflake, nil
+}
+
+// olderID compares snowflakes without parsing them
+// Snowflakes only grow in length, so shorter IDs are always older
+func olderID(a string, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// sortChannels orders channels from oldest to newest, so that every run processes
+// them in the same order regardless of how the API happened to list them
+func sortChannels(channels []Channel) {
+	sort.SliceStable(channels, func(i, j int) bool {
+		return olderID(channels[i].ID, channels[j].ID)
+	})
+}
@@ -14,3 +14,32 @@ func TestFromSnowflake(t *testing.T) {
 	v := fromSnowflake(838188033638400000)
 	assert.Equal(t, int64(1619910000000), v)
 }
+
+func TestParseSnowflake(t *testing.T) {
+	v, err := ParseSnowflake("838188033638400000")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(838188033638400000), v)
+}
+
+func TestParseInvalidSnowflake(t *testing.T) {
+	_, err := ParseSnowflake("DEADBEEF")
+	assert.NotNil(t, err)
+
+	_, err = ParseSnowflake("-1")
+	assert.NotNil(t, err)
+
+	// Far enough in the future that it can't exist yet
+	_, err = ParseSnowflake("9223372036854775807")
+	assert.NotNil(t, err)
+}
+
+func TestSortChannels(t *testing.T) {
+	channels := []Channel{{ID: "838188033638400000"}, {ID: "99"}, {ID: "175928847299117063"}, {ID: "100"}}
+	sortChannels(channels)
+
+	var ids []string
+	for _, channel := range channels {
+		ids = append(ids, channel.ID)
+	}
+	assert.Equal(t, []string{"99", "100", "175928847299117063", "838188033638400000"}, ids)
+}
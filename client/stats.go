@@ -0,0 +1,78 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of what a run has done so far
+type Stats struct {
+	Deleted  int64
+	Requests int64
+	// Requests made to each route, e.g. "GET /channels/:id/messages/search"
+	Routes map[string]int64
+}
+
+var snowflakeSegment = regexp.MustCompile(`/\d+`)
+
+type routeCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newRouteCounter() *routeCounter {
+	return &routeCounter{
+		counts: make(map[string]int64),
+	}
+}
+
+// route reduces an endpoint to its template, so that requests for different
+// channels and messages are counted together
+func route(method string, endpoint string) string {
+	if idx := strings.Index(endpoint, "?"); idx >= 0 {
+		endpoint = endpoint[:idx]
+	}
+	return method + " " + snowflakeSegment.ReplaceAllString(endpoint, "/:id")
+}
+
+func (r *routeCounter) add(method string, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counts[route(method, endpoint)]++
+}
+
+func (r *routeCounter) snapshot() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int64, len(r.counts))
+	for route, count := range r.counts {
+		counts[route] = count
+	}
+	return counts
+}
+
+func (c *Client) Stats() Stats {
+	return Stats{
+		Deleted:  atomic.LoadInt64(&c.deletedCount),
+		Requests: atomic.LoadInt64(&c.requestCount),
+		Routes:   c.routes.snapshot(),
+	}
+}
+
+func (s *Stats) logRoutes() {
+	var routes []string
+	for route := range s.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	for _, route := range routes {
+		log.Infof("%6v %v", s.Routes[route], route)
+	}
+}
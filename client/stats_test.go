@@ -0,0 +1,24 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	assert.Equal(t, "GET /users/@me", route("GET", "/users/@me"))
+	assert.Equal(t, "DELETE /channels/:id/messages/:id", route("DELETE", "/channels/123/messages/456"))
+	assert.Equal(t, "GET /guilds/:id/messages/search", route("GET", "/guilds/123/messages/search?author_id=456&offset=0"))
+}
+
+func TestRouteCounter(t *testing.T) {
+	r := newRouteCounter()
+	r.add("DELETE", "/channels/1/messages/2")
+	r.add("DELETE", "/channels/3/messages/4")
+	r.add("GET", "/users/@me")
+
+	assert.Equal(t, map[string]int64{
+		"DELETE /channels/:id/messages/:id": 2,
+		"GET /users/@me":                    1,
+	}, r.snapshot())
+}
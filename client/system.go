@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+)
+
+// Guild holds the details of a guild which aren't included when listing our guilds
+// The system channel is where Discord posts join messages and boosts, the rules and
+// public updates channels are set up by community guilds.
+type Guild struct {
+	ID                     string `json:"id"`
+	Name                   string `json:"name"`
+	SystemChannelID        string `json:"system_channel_id"`
+	RulesChannelID         string `json:"rules_channel_id"`
+	PublicUpdatesChannelID string `json:"public_updates_channel_id"`
+}
+
+// channelSet is a set of channel IDs which can be shared between concurrent passes
+type channelSet struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func newChannelSet() *channelSet {
+	return &channelSet{
+		ids: make(map[string]bool),
+	}
+}
+
+func (s *channelSet) add(id string) {
+	if id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ids[id] = true
+}
+
+func (s *channelSet) has(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ids[id]
+}
+
+// claim adds the ID to the set, reporting whether it wasn't already there
+func (s *channelSet) claim(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ids[id] {
+		return false
+	}
+	s.ids[id] = true
+	return true
+}
+
+// list returns the IDs in the set, oldest first
+func (s *channelSet) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return olderID(ids[i], ids[j])
+	})
+
+	return ids
+}
+
+func (c *Client) GuildDetails(guild *Channel) (*Guild, error) {
+	endpoint := fmt.Sprintf(endpoints["guild"], guild.ID)
+	var details Guild
+	err := c.request("GET", endpoint, nil, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// SetSkipSystemChannels controls whether a guild's system, rules and public updates
+// channels are skipped, which they are by default
+func (c *Client) SetSkipSystemChannels(skip bool) {
+	c.skipSystem = skip
+}
+
+// findSystemChannels adds the guild's system channels to those we skip
+// Failing to look them up isn't fatal, we just won't skip them
+func (c *Client) findSystemChannels(guild *Channel) {
+	if !c.skipSystem {
+		return
+	}
+
+	details, err := c.GuildDetails(guild)
+	if err != nil {
+		log.Warnf("Couldn't look up system channels for guild '%v', they won't be skipped: %v", guild.Name, err)
+		return
+	}
+
+	for _, id := range []string{details.SystemChannelID, details.RulesChannelID, details.PublicUpdatesChannelID} {
+		if id != "" {
+			log.Debugf("Skipping system channel %v in guild '%v'", id, guild.Name)
+			c.systemChannels.add(id)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+)
+
+// ErrorNotThread is returned when asked to delete from a thread by the ID of some other channel
+var ErrorNotThread = errors.New("Channel is not a thread")
+
+type ThreadMetadata struct {
+	Archived         bool   `json:"archived"`
+	ArchiveTimestamp string `json:"archive_timestamp"`
+}
+
+type Threads struct {
+	Threads []Channel `json:"threads"`
+	HasMore bool      `json:"has_more"`
+}
+
+// ActiveThreads returns every thread in the guild which hasn't been archived yet
+func (c *Client) ActiveThreads(guild *Channel) ([]Channel, error) {
+	endpoint := fmt.Sprintf(endpoints["guild_threads"], guild.ID)
+	var threads Threads
+	err := c.request("GET", endpoint, nil, &threads)
+	if err != nil {
+		return nil, err
+	}
+
+	return threads.Threads, nil
+}
+
+// ArchivedThreads returns every public thread in the channel which has been archived,
+// following the pages back from the most recently archived
+func (c *Client) ArchivedThreads(channel *Channel) ([]Channel, error) {
+	var all []Channel
+	before := ""
+
+	for {
+		endpoint := fmt.Sprintf(endpoints["archived_threads"], channel.ID)
+		if before != "" {
+			endpoint += "&before=" + url.QueryEscape(before)
+		}
+
+		var threads Threads
+		err := c.request("GET", endpoint, nil, &threads)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, threads.Threads...)
+
+		if !threads.HasMore || len(threads.Threads) == 0 {
+			return all, nil
+		}
+
+		last := threads.Threads[len(threads.Threads)-1]
+		if last.ThreadMetadata == nil {
+			return all, nil
+		}
+		before = last.ThreadMetadata.ArchiveTimestamp
+	}
+}
+
+// deleteFromForum deletes from each post in a forum or media channel, both open and archived
+func (c *Client) deleteFromForum(me *Me, guild *Channel, forum *Channel) error {
+	active, err := c.ActiveThreads(guild)
+	if err != nil {
+		return errors.Wrap(err, "Error fetching active threads")
+	}
+
+	archived, err := c.ArchivedThreads(forum)
+	if err != nil {
+		return errors.Wrap(err, "Error fetching archived threads")
+	}
+
+	for _, thread := range append(active, archived...) {
+		if thread.ParentID != forum.ID {
+			continue
+		}
+
+		err = c.DeleteFromChannel(me, &thread)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isThread(channel *Channel) bool {
+	switch channel.Type {
+	case AnnouncementThread, PublicThread, PrivateThread:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeleteFromThread deletes messages from a single thread, archived or not, without
+// listing anything else in its guild
+func (c *Client) DeleteFromThread(id string) error {
+	me, err := c.profile()
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.Channel(id)
+	if hasStatus(err, http.StatusNotFound, http.StatusForbidden) {
+		return fmt.Errorf("No thread with ID %v that we can read", id)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error fetching thread")
+	}
+	if !isThread(thread) {
+		return errors.Wrapf(ErrorNotThread, "%v is a %v", id, ChannelTypeName(thread.Type))
+	}
+
+	log.Infof("Deleting from thread '%v' only", thread.Name)
+	err = c.DeleteFromChannel(me, thread)
+	if err != nil {
+		return err
+	}
+
+	c.logSummary()
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeleteFromThread(t *testing.T) {
+	search := searchServer(map[string]int{"1": 30}, 0)
+	defer search.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/@me":
+			fmt.Fprint(w, `{"id":"me","username":"someone"}`)
+		case r.URL.Path == "/channels/1":
+			fmt.Fprintf(w, `{"id":"1","type":%v,"name":"a thread","thread_metadata":{"archived":true}}`, PublicThread)
+		case r.URL.Path == "/channels/2":
+			fmt.Fprintf(w, `{"id":"2","type":%v,"name":"general"}`, GuildText)
+		case strings.HasSuffix(r.URL.Path, "/messages/search"):
+			search.Config.Handler.ServeHTTP(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New("token")
+	c.baseURL = server.URL
+	c.SetDryRun(true)
+
+	assert.Nil(t, c.DeleteFromThread("1"))
+	assert.Equal(t, int64(30), c.DeletedCount())
+
+	err := c.DeleteFromThread("2")
+	assert.Equal(t, ErrorNotThread, errors.Cause(err))
+
+	err = c.DeleteFromThread("3")
+	assert.EqualError(t, err, "No thread with ID 3 that we can read")
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Upper bounds of each histogram bucket, anything slower falls into a final overflow bucket
+var timingBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+type timingHistogram struct {
+	mu     sync.Mutex
+	counts []int
+	total  time.Duration
+	count  int
+}
+
+func newTimingHistogram() *timingHistogram {
+	return &timingHistogram{
+		counts: make([]int, len(timingBuckets)+1),
+	}
+}
+
+func (h *timingHistogram) add(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.total += d
+	h.count++
+
+	for i, bound := range timingBuckets {
+		if d <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+	h.counts[len(timingBuckets)]++
+}
+
+func (h *timingHistogram) lines() []string {
+	var lines []string
+	for i, count := range h.counts {
+		var label string
+		if i < len(timingBuckets) {
+			label = fmt.Sprintf("<= %v", timingBuckets[i])
+		} else {
+			label = fmt.Sprintf("> %v", timingBuckets[len(timingBuckets)-1])
+		}
+		lines = append(lines, fmt.Sprintf("%-8v %v", label, count))
+	}
+	return lines
+}
+
+func (h *timingHistogram) log() {
+	if h.count == 0 {
+		return
+	}
+
+	log.Debugf("Request durations (excluding rate limit sleeps), %v requests averaging %v:", h.count, h.total/time.Duration(h.count))
+	for _, line := range h.lines() {
+		log.Debug(line)
+	}
+}
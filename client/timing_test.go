@@ -0,0 +1,18 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimingHistogramBuckets(t *testing.T) {
+	h := newTimingHistogram()
+	h.add(50 * time.Millisecond)
+	h.add(100 * time.Millisecond)
+	h.add(300 * time.Millisecond)
+	h.add(10 * time.Second)
+
+	assert.Equal(t, []int{2, 0, 1, 0, 0, 0, 1}, h.counts)
+	assert.Equal(t, 4, h.count)
+}
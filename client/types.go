@@ -0,0 +1,64 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+)
+
+// typeCounter keeps a tally of the message types we come across, so that users can
+// see which types exist on their account and whether any more should be deletable
+type typeCounter struct {
+	mu      sync.Mutex
+	enabled bool
+	counts  map[int]int
+}
+
+func newTypeCounter() *typeCounter {
+	return &typeCounter{
+		counts: make(map[int]int),
+	}
+}
+
+func (t *typeCounter) add(msg *Message) {
+	if !t.enabled {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts[msg.Type]++
+}
+
+func (t *typeCounter) logMessage(msg *Message) {
+	if !t.enabled {
+		return
+	}
+
+	log.Infof("Found undeletable message of type %v: %s", msg.Type, msg.raw)
+}
+
+func (t *typeCounter) log() {
+	if !t.enabled {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var types []int
+	for msgType := range t.counts {
+		types = append(types, msgType)
+	}
+	sort.Ints(types)
+
+	log.Infof("Message types seen:")
+	for _, msgType := range types {
+		deletable := "undeletable"
+		if deletableTypes[msgType] {
+			deletable = "deletable"
+		}
+		log.Infof("%-4v %-12v %v", msgType, deletable, t.counts[msgType])
+	}
+}
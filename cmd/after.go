@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var afterMessageCmd = &cobra.Command{
+	Use:   "after-message <message ID>",
+	Short: "Delete every message sent after the given message",
+	Args:  cobra.ExactArgs(1),
+	Run:   afterMessage,
+}
+
+func afterMessage(cmd *cobra.Command, args []string) {
+	id, err := client.ParseSnowflake(args[0])
+	if err != nil {
+		failUsage(err)
+	}
+
+	c, done := newClient()
+	defer done()
+
+	// Bounds are inclusive, so start from the next possible ID to leave the message itself alone
+	c.SetMinID(id + 1)
+	log.Infof("Deleting messages sent after message %v", id)
+
+	err = run(&c)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	addDeleteFlags(afterMessageCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var countBeforeAfter bool
+
+// census counts every message we've sent in open DMs and guilds for --count-before-after,
+// logging how many requests it took since each DM and guild costs a search
+func census(c *client.Client, when string) (int, error) {
+	requests := c.RequestCount()
+	total, err := c.MessageTotal()
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error counting messages %v the run", when)
+	}
+
+	log.Infof("Counted %v messages %v the run in %v requests", total, when, c.RequestCount()-requests)
+	return total, nil
+}
+
+// reportCensus counts again after the run and logs the difference
+func reportCensus(c *client.Client, before int) {
+	after, err := census(c, "after")
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	if dryrun {
+		log.Infof("Would have deleted %v of %v messages", c.DeletedCount(), before)
+		return
+	}
+	log.Infof("Deleted %v of %v messages, %v remain (the search can take a while to catch up with deletions)", before-after, before, after)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bufio"
+	"discord-delete/client"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// chooseChannels finds the channels matching --channel-name, asking before each one
+// unless --yes was passed
+func chooseChannels(c *client.Client) ([]client.GuildChannel, error) {
+	found, err := c.FindChannels(channelName)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Found %v channels named '%v'", len(found), channelName)
+	if yes || len(found) == 0 {
+		return found, nil
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, fmt.Errorf("Can't ask which channels to delete from without a terminal, pass --yes to delete from all of them")
+	}
+
+	var chosen []client.GuildChannel
+	reader := bufio.NewReader(os.Stdin)
+	for _, target := range found {
+		fmt.Printf("Delete from #%v in '%v'? [y/N] ", target.Channel.Name, target.Guild.Name)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(strings.TrimSpace(answer), "y") {
+			chosen = append(chosen, target)
+		}
+	}
+
+	return chosen, nil
+}
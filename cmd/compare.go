@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <predicted> <actual>",
+	Short: "Compare the output of a dry run against the output of a real run",
+	Args:  cobra.ExactArgs(2),
+	Run:   compare,
+}
+
+func compare(cmd *cobra.Command, args []string) {
+	predicted, err := readExport(args[0])
+	if err != nil {
+		fail(err)
+	}
+
+	actual, err := readExport(args[1])
+	if err != nil {
+		fail(err)
+	}
+
+	cmp := client.Compare(predicted, actual)
+
+	for _, id := range cmp.Missed {
+		log.Debugf("Predicted message %v was not deleted", id)
+	}
+	for _, id := range cmp.Unexpected {
+		log.Debugf("Message %v was deleted without being predicted", id)
+	}
+
+	log.Infof("%v matched, %v missed, %v unexpected", len(cmp.Matched), len(cmp.Missed), len(cmp.Unexpected))
+}
+
+func readExport(path string) (map[string]client.Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return client.ReadExport(file)
+}
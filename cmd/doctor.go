@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common token and network problems without deleting anything",
+	Args:  cobra.NoArgs,
+	Run:   doctor,
+}
+
+// Every check only reads from the API
+type check struct {
+	name     string
+	critical bool
+	run      func(c *client.Client) (string, error)
+}
+
+var checks = []check{
+	{"API is reachable", true, func(c *client.Client) (string, error) {
+		if !c.Reachable() {
+			return "", fmt.Errorf("Couldn't connect to Discord, check your network or proxy settings")
+		}
+		return "connected", nil
+	}},
+	{"Token is valid", true, func(c *client.Client) (string, error) {
+		me, err := c.Me()
+		if err != nil {
+			return "", err
+		}
+		if me.ID == "" {
+			return "", fmt.Errorf("Discord didn't return a profile for this token")
+		}
+		return fmt.Sprintf("logged in as %v (%v)", me.Username, me.ID), nil
+	}},
+	{"Channels are readable", true, func(c *client.Client) (string, error) {
+		channels, err := c.Channels()
+		return fmt.Sprintf("%v open channels", len(channels)), err
+	}},
+	{"Relationships are readable", false, func(c *client.Client) (string, error) {
+		relationships, err := c.Relationships()
+		return fmt.Sprintf("%v relationships", len(relationships)), err
+	}},
+	{"Guilds are readable", false, func(c *client.Client) (string, error) {
+		guilds, err := c.Guilds()
+		return fmt.Sprintf("%v guilds", len(guilds)), err
+	}},
+}
+
+func doctor(cmd *cobra.Command, args []string) {
+	tok, err := lookupToken()
+	if err != nil {
+		fmt.Printf("[FAIL] Token found: %v, pass DISCORD_TOKEN as an environment variable instead\n", err)
+		os.Exit(exitCode(err))
+	}
+	if _, def := os.LookupEnv("DISCORD_TOKEN"); def {
+		fmt.Println("[ OK ] Token found: from DISCORD_TOKEN")
+	} else {
+		fmt.Println("[ OK ] Token found: from the Discord client")
+	}
+
+	c := client.New(tok)
+	configureTLS(&c)
+	configureBaseURL(&c)
+	configureHeaders(&c)
+	var failed error
+
+	for _, chk := range checks {
+		detail, err := chk.run(&c)
+		switch {
+		case err == nil:
+			fmt.Printf("[ OK ] %v: %v\n", chk.name, detail)
+		case chk.critical:
+			fmt.Printf("[FAIL] %v: %v\n", chk.name, err)
+			failed = err
+		default:
+			fmt.Printf("[WARN] %v: %v\n", chk.name, err)
+		}
+
+		// Nothing else will work without a connection and a valid token
+		if failed != nil {
+			break
+		}
+	}
+
+	// The results are already printed, so only the exit code is left to report
+	if failed != nil {
+		os.Exit(exitCode(failed))
+	}
+}
@@ -0,0 +1,26 @@
+//+build linux
+
+package cmd
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// hideInput stops the terminal echoing what's typed, returning a function to turn it back on
+func hideInput(fd int) (func(), error) {
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := *state
+	hidden.Lflag &^= unix.ECHO
+	err = unix.IoctlSetTermios(fd, unix.TCSETS, &hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(fd, unix.TCSETS, state)
+	}, nil
+}
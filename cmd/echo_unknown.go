@@ -0,0 +1,8 @@
+//+build !windows,!linux
+
+package cmd
+
+// hideInput isn't supported on this platform yet
+func hideInput(fd int) (func(), error) {
+	return nil, ErrorHiddenInput
+}
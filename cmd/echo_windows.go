@@ -0,0 +1,27 @@
+//+build windows
+
+package cmd
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// hideInput stops the console echoing what's typed, returning a function to turn it back on
+func hideInput(fd int) (func(), error) {
+	handle := windows.Handle(fd)
+
+	var mode uint32
+	err := windows.GetConsoleMode(handle, &mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		windows.SetConsoleMode(handle, mode)
+	}, nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"discord-delete/client"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	estimateOnly bool
+	confirmOver  time.Duration
+)
+
+// The estimate comes from searching the whole account, the same way the plan command does
+var ErrorEstimateScope = errors.New("--estimate and --confirm-over only work for runs over the whole account, not a thread, --only, --channels-file, --category, --channel-name, --recipient, --only-file or import")
+
+// narrowed reports whether the run only covers part of the account, which the estimate
+// has no way of telling apart from the rest
+func narrowed() bool {
+	return packageMessages != nil || threadID != "" || jobFile != "" || len(only) > 0 || channelsFile != "" ||
+		category != "" || channelName != "" || len(recipients) > 0
+}
+
+// checkEstimate prints how long the run should take when asked to, reporting whether
+// to go ahead: not after --estimate, and only with confirmation past --confirm-over
+// Scheduled runs only estimate before the first, rather than searching everything again each time
+func checkEstimate(c *client.Client) (bool, error) {
+	if continuing || (!estimateOnly && confirmOver == 0) {
+		return true, nil
+	}
+	if narrowed() {
+		return false, ErrorEstimateScope
+	}
+
+	est, err := c.Estimate()
+	if err != nil {
+		return false, err
+	}
+	log.Infof("Estimated %v messages across %v channels, taking about %v (closed DMs aren't included)", est.Messages, est.Channels, est.Duration.Round(time.Minute))
+
+	if estimateOnly {
+		return false, nil
+	}
+	if est.Duration <= confirmOver || yes {
+		return true, nil
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("Can't ask whether to start a run longer than %v without a terminal, pass --yes to start anyway", confirmOver)
+	}
+
+	fmt.Printf("That's longer than %v, start anyway? [y/N] ", confirmOver)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		log.Info("Not starting")
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"discord-delete/client/token"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"os"
+)
+
+// Exit codes let wrapping scripts tell failures apart, e.g. to retry after a
+// network outage but not after a bad token
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitToken   = 3
+	exitNetwork = 4
+	exitServer  = 5
+
+	// Matches the shell's own code for a process killed by SIGINT
+	exitInterrupted = 130
+)
+
+// usageError is a problem with the flags or arguments themselves, rather than anything
+// that happened during the run
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+// exitCode maps an error to the exit code for its category
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	cause := errors.Cause(err)
+	switch cause.(type) {
+	case *client.TokenInvalidatedError:
+		return exitToken
+	case *client.StatusError:
+		if cause.(*client.StatusError).StatusCode >= 500 {
+			return exitServer
+		}
+		return exitFailed
+	case net.Error:
+		return exitNetwork
+	case *usageError:
+		return exitUsage
+	}
+
+	switch cause {
+	case client.ErrorInterrupted:
+		return exitInterrupted
+	case ErrorEstimateScope:
+		return exitUsage
+	case ErrorNoToken, client.ErrorUnauthorized, client.ErrorLoginRejected, token.ErrorTokenRetrieve, token.ErrorTokenPlatform, token.ErrorTokenInvalid:
+		return exitToken
+	}
+
+	return exitFailed
+}
+
+// fail logs the error and exits with the code for its category
+func fail(err error) {
+	log.Error(err)
+	os.Exit(exitCode(err))
+}
+
+// failUsage logs the error and exits the same way as invalid flags cobra catches itself,
+// for flags which are invalid or can't be used together
+func failUsage(err error) {
+	fail(&usageError{err})
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Set by the import command, in place of searching
+var packageMessages []client.Message
+
+var importCmd = &cobra.Command{
+	Use:   "import <data package directory>",
+	Short: "Delete the messages listed in an extracted Discord data package, without searching",
+	Args:  cobra.ExactArgs(1),
+	Run:   importPackage,
+}
+
+func importPackage(cmd *cobra.Command, args []string) {
+	// Messages from the package are deleted without listing their channels, so there's
+	// no read state to go by
+	if readOnly || unreadOnly {
+		failUsage(errors.New("--read-only and --unread-only can't be used with import"))
+	}
+
+	var err error
+	packageMessages, err = client.ReadDataPackage(args[0])
+	if err != nil {
+		fail(err)
+	}
+	if len(packageMessages) == 0 {
+		log.Info("The data package doesn't list any messages, nothing to delete")
+		return
+	}
+	log.Infof("Read %v messages from the data package", len(packageMessages))
+
+	c, done := newClient()
+	defer done()
+
+	err = run(&c)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	addDeleteFlags(importCmd)
+}
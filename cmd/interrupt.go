@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"discord-delete/client"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// listenInterrupt returns a context which the first Ctrl-C cancels, so the run stops
+// straight away, even part way through a long rate limit sleep. A second Ctrl-C exits
+// without waiting. stop should be called once the run is over.
+func listenInterrupt() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			log.Warn("Interrupted, stopping (press Ctrl-C again to quit immediately)")
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case <-signals:
+			os.Exit(exitInterrupted)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// wait sleeps for d outside of a run, returning client.ErrorInterrupted straight away
+// if Ctrl-C is pressed first
+func wait(d time.Duration) error {
+	ctx, stop := listenInterrupt()
+	defer stop()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return client.ErrorInterrupted
+	case <-timer.C:
+		return nil
+	}
+}
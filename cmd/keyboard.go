@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bufio"
+	"discord-delete/client"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+// keyboard is shared by every run in the process, since only one can read stdin
+var keyboard *client.Control
+
+// listenKeyboard lets a run be controlled by typing a command followed by enter:
+// p to pause once the current page is done, r to resume, q to save and quit
+// It returns nil when stdin isn't a terminal, e.g. under a scheduler or in a pipe
+func listenKeyboard() *client.Control {
+	if keyboard != nil {
+		return keyboard
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+
+	ctl := client.NewControl()
+	keyboard = ctl
+	log.Info("Type p and press enter to pause, r to resume or q to save progress and quit")
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "p":
+				log.Info("Pausing after the current page")
+				ctl.Pause()
+			case "r":
+				ctl.Resume()
+			case "q":
+				log.Info("Stopping after the current page")
+				ctl.Stop()
+				return
+			}
+		}
+	}()
+
+	return ctl
+}
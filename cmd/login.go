@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bufio"
+	"discord-delete/client"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in with an email and password, printing a token for DISCORD_TOKEN",
+	Long: `Log in with an email and password, printing a token for DISCORD_TOKEN
+
+The token is printed on its own so it can be captured for the current shell only:
+  export DISCORD_TOKEN=$(discord-delete login)
+The password is read from DISCORD_PASSWORD if it's set, otherwise it's prompted for
+without being shown as it's typed. Nothing is saved to disk.`,
+	Args: cobra.NoArgs,
+	Run:  login,
+}
+
+func login(cmd *cobra.Command, args []string) {
+	log.Warn("Logging in from an unofficial client can trigger Discord's security checks, and may get your account locked")
+
+	input := bufio.NewReader(os.Stdin)
+	email, err := prompt(input, "Email: ")
+	if err != nil {
+		fail(err)
+	}
+	email = strings.TrimSpace(email)
+
+	password, def := os.LookupEnv("DISCORD_PASSWORD")
+	if !def {
+		password, err = promptPassword(input, "Password: ")
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	c := client.New("")
+	configureTLS(&c)
+	configureBaseURL(&c)
+	configureHeaders(&c)
+
+	tok, err := c.Login(email, password, func() (string, error) {
+		code, err := prompt(input, "Two factor code: ")
+		return strings.TrimSpace(code), err
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	log.Info("Logged in, the token stays valid until you log out or change your password")
+	fmt.Println(tok)
+}
+
+// prompt asks on stderr, so that stdout only ever holds the token
+func prompt(input *bufio.Reader, question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := input.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", err
+	}
+	return strings.TrimRight(answer, "\r\n"), nil
+}
+
+// ErrorHiddenInput is returned when the terminal can't be stopped from echoing the password
+var ErrorHiddenInput = errors.New("Can't hide the password as it's typed on this platform, set DISCORD_PASSWORD instead")
+
+// promptPassword asks for a password without echoing it, when stdin is a terminal
+// Piped input isn't echoed anyway, so it's read as it is
+func promptPassword(input *bufio.Reader, question string) (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return prompt(input, question)
+	}
+
+	restore, err := hideInput(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	password, err := prompt(input, question)
+	// The enter key wasn't echoed either
+	fmt.Fprintln(os.Stderr)
+	return password, err
+}
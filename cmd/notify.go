@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"discord-delete/client"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// The summary is sent as-is to generic webhooks, with content and text
+// included so that it also renders in Discord and Slack respectively
+type summary struct {
+	Content  string `json:"content"`
+	Text     string `json:"text"`
+	Deleted  int64  `json:"deleted"`
+	Requests int64  `json:"requests"`
+	Error    string `json:"error,omitempty"`
+}
+
+func notify(url string, c *client.Client, runErr error) error {
+	s := summary{
+		Deleted:  c.DeletedCount(),
+		Requests: c.RequestCount(),
+	}
+
+	if runErr != nil {
+		s.Error = runErr.Error()
+		s.Content = fmt.Sprintf("discord-delete failed after deleting %v messages: %v", s.Deleted, s.Error)
+	} else {
+		s.Content = fmt.Sprintf("discord-delete finished, %v messages deleted in %v requests", s.Deleted, s.Requests)
+	}
+	s.Text = s.Content
+
+	body, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "Error encoding notification")
+	}
+
+	// The default transport already respects HTTP_PROXY and friends
+	httpClient := http.Client{Timeout: 30 * time.Second}
+	res, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "Error sending notification")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("Webhook returned bad status code %v", http.StatusText(res.StatusCode))
+	}
+
+	log.Debugf("Sent notification to webhook")
+
+	return nil
+}
@@ -3,16 +3,84 @@ package cmd
 import (
 	"discord-delete/client"
 	"discord-delete/client/token"
+	"fmt"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 var (
-	dryrun       bool
-	minAge       uint
-	maxAge       uint
-	skipChannels []string
+	dryrun        bool
+	bestEffort    bool
+	minAge        uint
+	maxAge        uint
+	minID         int64
+	maxID         int64
+	skipChannels  []string
+	output        string
+	timestampsCSV string
+	retryAfter    time.Duration
+	channelScan   bool
+	skipRelations bool
+	relationTypes []int
+	recipients    []string
+	networkWait   time.Duration
+	strategy      string
+	manifest      string
+	webhook       string
+	dormant       string
+	logTypes      bool
+	resumeFile    string
+	planFile      string
+	planHash      string
+	minLength     int
+	maxLength     int
+	mentions      []string
+	only          []string
+	channelsFile  string
+	protect       []string
+	noReopenDMs   bool
+	noDedup       bool
+	strictFields  bool
+	startPhase    string
+	guilds        []string
+	editedOnly    bool
+	hours         string
+	days          []string
+	timezone      string
+	skipRepliedTo bool
+	readOnly      bool
+	unreadOnly    bool
+	repliesTo     string
+	cooldown      time.Duration
+	batchSize     int
+	batchCooldown time.Duration
+	slowmodeDelay time.Duration
+	force         bool
+	forceAccount  bool
+	emptyRetries  int
+	jobFile       string
+	linksOnly     bool
+	noSkipSystem  bool
+	cautious      bool
+	category      string
+	archive       string
+	channelName   string
+	yes           bool
+	perChannel    int
+	oldestPercent float64
+	webhooksOnly  bool
+	noWebhooks    bool
+	repliesOnly   bool
+	noReplies     bool
+	onForbidden   string
+	limit         int64
+	logEvery      int
+	serverRetries int
 )
 
 var partialCmd = &cobra.Command{
@@ -21,38 +89,485 @@ var partialCmd = &cobra.Command{
 }
 
 func partial(cmd *cobra.Command, args []string) {
-	if verbose {
-		log.SetLevel(log.DebugLevel)
+	if every > 0 {
+		schedule()
+		return
 	}
 
-	log.Warn("Any tool that deletes your messages, including this one, could result in the termination of your account")
-	log.Warn("You have been warned!")
+	client, done := newClient()
+	defer done()
 
-	var tok string
-	var err error
+	err := run(&client)
+	if err != nil {
+		fail(err)
+	}
+}
 
-	tok, def := os.LookupEnv("DISCORD_TOKEN")
+// run deletes messages from everywhere, unless the flags have narrowed things down
+func run(c *client.Client) error {
+	// Ask before the keyboard is taken over for pausing
+	start, err := checkEstimate(c)
+	if err != nil || !start {
+		return err
+	}
 
+	var targets []client.GuildChannel
+	if channelName != "" {
+		targets, err = chooseChannels(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	before := 0
+	if countBeforeAfter {
+		before, err = census(c, "before")
+		if err != nil {
+			return err
+		}
+	}
+
+	ctl := listenKeyboard()
+	if ctl != nil {
+		c.SetControl(ctl)
+	}
+
+	ctx, stop := listenInterrupt()
+	defer stop()
+	c.SetContext(ctx)
+
+	switch {
+	case packageMessages != nil:
+		err = c.DeleteFromPackage(packageMessages)
+	case threadID != "":
+		err = c.DeleteFromThread(threadID)
+	case jobFile != "":
+		err = runJob(c)
+	case len(only) > 0 || channelsFile != "":
+		err = deleteFromChannelIDs(c)
+	case category != "":
+		err = c.DeleteFromCategory(category)
+	case channelName != "":
+		err = c.DeleteFromGuildChannels(targets)
+	case len(recipients) > 0:
+		err = c.DeleteFromRecipients(recipients)
+	default:
+		err = c.PartialDelete()
+	}
+
+	if errors.Cause(err) == client.ErrorLimitReached {
+		err = nil
+		if resumeFile != "" {
+			log.Infof("Progress saved to %v, run again with the same flags to delete the next %v", resumeFile, limit)
+		} else {
+			log.Warn("Reached --limit without --resume-file, the next run will start from the beginning")
+		}
+	}
+
+	if errors.Cause(err) == client.ErrorStopped {
+		err = nil
+		if resumeFile != "" {
+			log.Infof("Progress saved to %v, run again with the same flags to resume", resumeFile)
+		} else {
+			log.Warn("Stopped without --resume-file, the next run will start from the beginning")
+		}
+	}
+
+	// Still a failure, but say where to pick up from
+	if errors.Cause(err) == client.ErrorInterrupted && resumeFile != "" {
+		log.Infof("Progress saved to %v, run again with the same flags to resume", resumeFile)
+	}
+
+	// Report a revoked token on its own rather than buried in whatever request hit it
+	if invalid, ok := errors.Cause(err).(*client.TokenInvalidatedError); ok {
+		err = invalid
+		if resumeFile != "" {
+			log.Infof("Progress saved to %v, run again with a fresh token to resume", resumeFile)
+		}
+	}
+
+	if err == nil {
+		err = c.FinishPlan()
+	}
+
+	if countBeforeAfter && err == nil {
+		reportCensus(c, before)
+	}
+
+	// Quiet mode hides the usual summary along with every other info log
+	if quiet {
+		if err != nil {
+			fmt.Printf("Failed after deleting %v messages in %v requests\n", c.DeletedCount(), c.RequestCount())
+		} else {
+			fmt.Printf("Deleted %v messages in %v requests\n", c.DeletedCount(), c.RequestCount())
+		}
+	}
+
+	if webhook != "" {
+		notifyErr := notify(webhook, c, err)
+		if notifyErr != nil {
+			log.Warn(notifyErr)
+		}
+	}
+
+	return err
+}
+
+// deleteFromChannelIDs deletes from the channels given with --only and in --channels-file
+func deleteFromChannelIDs(c *client.Client) error {
+	ids := only
+	if channelsFile != "" {
+		fromFile, err := client.ReadChannelIDs(channelsFile)
+		if err != nil {
+			return err
+		}
+		ids = append(append([]string{}, only...), fromFile...)
+	}
+
+	return c.DeleteFromChannelIDs(ids)
+}
+
+func runJob(c *client.Client) error {
+	file, err := os.Open(jobFile)
+	if err != nil {
+		return errors.Wrap(err, "Error opening job file")
+	}
+	defer file.Close()
+
+	job, err := client.ReadJob(file)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteFromJob(job)
+}
+
+// ErrorNoToken is returned when there's no token to use, before making any requests
+var ErrorNoToken = errors.New("No token found, set DISCORD_TOKEN (see discord-delete login) or log in to the Discord client on this machine (Windows and Linux only)")
+
+// lookupToken prefers DISCORD_TOKEN, falling back to the token stored by the Discord client
+func lookupToken() (string, error) {
+	tok, def := os.LookupEnv("DISCORD_TOKEN")
 	if !def {
+		var err error
 		tok, err = token.GetToken()
 		if err != nil {
+			return "", err
+		}
+	}
+
+	if strings.TrimSpace(tok) == "" {
+		return "", ErrorNoToken
+	}
+	return tok, nil
+}
+
+// newClient builds a client from the flags shared between every deletion command
+// The returned function must be called once the client is finished with
+func newClient() (client.Client, func()) {
+	log.Warn("Any tool that deletes your messages, including this one, could result in the termination of your account")
+	log.Warn("You have been warned!")
+
+	tok, err := lookupToken()
+	if err != nil {
+		if err != ErrorNoToken {
 			log.Debug(err)
-			log.Fatal("Error retrieving token, pass DISCORD_TOKEN as an environment variable instead")
+			err = ErrorNoToken
+		}
+		fail(err)
+	}
+
+	// Load these before the client variable shadows the package
+	var checkpoint *client.Checkpoint
+	if resumeFile != "" {
+		checkpoint, err = client.LoadCheckpoint(resumeFile)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	var repliesToID int64
+	if repliesTo != "" {
+		repliesToID, err = client.ParseSnowflake(repliesTo)
+		if err != nil {
+			failUsage(errors.Wrap(err, "Invalid --replies-to"))
 		}
 	}
 
+	var webhookFilter string
+	switch {
+	case webhooksOnly && noWebhooks:
+		failUsage(errors.New("--webhooks-only and --no-webhooks can't be used together"))
+	case webhooksOnly:
+		webhookFilter = client.WebhooksOnly
+	case noWebhooks:
+		webhookFilter = client.WebhooksExclude
+	}
+
+	var replyFilter string
+	switch {
+	case repliesOnly && noReplies:
+		failUsage(errors.New("--replies-only and --no-replies can't be used together"))
+	case noReplies && repliesTo != "":
+		failUsage(errors.New("--replies-to and --no-replies can't be used together"))
+	case repliesOnly:
+		replyFilter = client.RepliesOnly
+	case noReplies:
+		replyFilter = client.RepliesExclude
+	}
+
+	var readFilter string
+	switch {
+	case readOnly && unreadOnly:
+		failUsage(errors.New("--read-only and --unread-only can't be used together"))
+	case readOnly:
+		readFilter = client.ReadOnly
+	case unreadOnly:
+		readFilter = client.UnreadOnly
+	}
+
+	if oldestPercent > 0 && resumeFile != "" {
+		// The checkpoint would mark each channel finished, so the next run wouldn't take its share
+		failUsage(errors.New("--oldest-percent and --resume-file can't be used together, each run works out its own cutoff"))
+	}
+	if oldestPercent > 0 && channelScan {
+		// The channel scans would each take another share of what the guild pass left
+		failUsage(errors.New("--oldest-percent and --per-channel-guild-scan can't be used together, each guild would lose more than its share"))
+	}
+
+	if planFile != "" && !dryrun && planHash == "" {
+		failUsage(errors.New("--plan-file needs --plan-hash for a real run, the hash the dry run logged and that was reviewed"))
+	}
+	if planHash != "" && (planFile == "" || dryrun) {
+		failUsage(errors.New("--plan-hash is only for real runs with --plan-file"))
+	}
+
+	if planFile != "" && resumeFile != "" {
+		// A resumed run wouldn't delete what the earlier runs already had
+		failUsage(errors.New("--plan-file and --resume-file can't be used together, the plan has to be carried out in one run"))
+	}
+
 	client := client.New(tok)
+	configureTLS(&client)
+	configureBaseURL(&client)
+	configureHeaders(&client)
 	client.SetDryRun(dryrun)
+	client.SetBestEffort(bestEffort)
 	client.SetSkipChannels(skipChannels)
-	
+	client.SetPerChannelGuildScan(channelScan)
+	client.SetSkipRelationships(skipRelations)
+	client.SetNoReopenDMs(noReopenDMs)
+	client.SetNoRelationDedup(noDedup)
+	client.SetStrictFields(strictFields)
+	client.SetNetworkWait(networkWait)
+	client.SetChannelCooldown(cooldown)
+	client.SetBatch(batchSize, batchCooldown)
+	client.SetSlowmodeDelay(slowmodeDelay)
+	client.SetMaxPerChannel(perChannel)
+	client.SetLimit(limit)
+	client.SetLogEvery(logEvery)
+	client.SetServerRetries(serverRetries)
+	client.SetSkipSystemChannels(!noSkipSystem)
+	client.SetCautious(cautious)
+	client.SetEmptyPageRetries(emptyRetries)
+	client.SetForce(force)
+	if dir, err := os.UserConfigDir(); err == nil {
+		client.SetMarkerDir(filepath.Join(dir, "discord-delete", "clean"))
+	}
+	client.SetManifestPath(manifest)
+	client.SetLogMessageTypes(logTypes)
+
+	err = client.SetMaxRetryAfter(retryAfter)
+	if err != nil {
+		failUsage(err)
+	}
+
+	err = client.SetStrategy(strategy)
+	if err != nil {
+		failUsage(err)
+	}
+
+	err = client.SetStartPhase(startPhase)
+	if err != nil {
+		failUsage(err)
+	}
+	client.SetGuilds(guilds)
+
 	if dryrun {
 		log.Infof("No messages will be deleted in dry-run mode")
 	}
 
+	done := func() {}
+
+	if output != "" {
+		file, err := createOutput(output)
+		if err != nil {
+			fail(err)
+		}
+		done = func() {
+			file.Close()
+		}
+
+		client.SetExport(file)
+		log.Infof("Writing deleted messages to %v", output)
+	}
+
+	if archive != "" {
+		// Append so that resumed runs add to the same archive
+		file, err := os.OpenFile(archive, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fail(err)
+		}
+		closeExport := done
+		done = func() {
+			closeExport()
+			file.Close()
+		}
+
+		client.SetArchive(file)
+		log.Infof("Archiving messages in full to %v", archive)
+	}
+
+	if timestampsCSV != "" {
+		file, err := createOutput(timestampsCSV)
+		if err != nil {
+			fail(err)
+		}
+		closeArchive := done
+		done = func() {
+			closeArchive()
+			file.Close()
+		}
+
+		err = client.SetTimestamps(file, !continuing)
+		if err != nil {
+			fail(err)
+		}
+		log.Infof("Writing the time each deleted message was sent to %v", timestampsCSV)
+	}
+
+	client.SetLengthFilter(minLength, maxLength)
+	if minLength > 0 {
+		log.Infof("Deleting messages at least %v characters long", minLength)
+	}
+	if maxLength > 0 {
+		log.Infof("Deleting messages at most %v characters long", maxLength)
+	}
+
+	if len(mentions) > 0 {
+		client.SetMentionFilter(mentions)
+		log.Infof("Deleting messages mentioning %v", strings.Join(mentions, ", "))
+	}
+
+	if len(protect) > 0 {
+		client.SetProtectWords(protect)
+		log.Infof("Keeping messages containing %v", strings.Join(protect, ", "))
+	}
+
+	if repliesToID > 0 {
+		client.SetRepliesTo(repliesToID)
+		log.Infof("Deleting replies to user %v", repliesToID)
+	}
+
+	err = client.SetWebhookFilter(webhookFilter)
+	if err != nil {
+		failUsage(err)
+	}
+	if webhooksOnly {
+		log.Info("Deleting messages sent through webhooks only")
+	}
+	if noWebhooks {
+		log.Info("Leaving messages sent through webhooks alone")
+	}
+
+	err = client.SetOldestPercent(oldestPercent)
+	if err != nil {
+		failUsage(err)
+	}
+
+	err = client.SetRelationshipTypes(relationTypes)
+	if err != nil {
+		failUsage(err)
+	}
+
+	err = client.SetForbidden(onForbidden)
+	if err != nil {
+		failUsage(err)
+	}
+
+	err = client.SetReplyFilter(replyFilter)
+	if err != nil {
+		failUsage(err)
+	}
+	if repliesOnly {
+		log.Info("Deleting replies only")
+	}
+	if noReplies {
+		log.Info("Leaving replies alone")
+	}
+
+	err = client.SetReadStateFilter(readFilter)
+	if err != nil {
+		failUsage(err)
+	}
+	if readFilter != "" {
+		log.Infof("Deleting from %v channels only", readFilter)
+	}
+
+	if linksOnly {
+		client.SetLinksOnly(linksOnly)
+		log.Info("Deleting messages containing links only")
+	}
+
+	if editedOnly {
+		client.SetEditedOnly(editedOnly)
+		log.Info("Deleting edited messages only")
+	}
+
+	err = client.SetHours(hours)
+	if err != nil {
+		failUsage(err)
+	}
+	err = client.SetDays(days)
+	if err != nil {
+		failUsage(err)
+	}
+	err = client.SetTimezone(timezone)
+	if err != nil {
+		failUsage(err)
+	}
+	if hours != "" {
+		log.Infof("Deleting messages sent between hours %v (%v time)", hours, timezone)
+	}
+	if len(days) > 0 {
+		log.Infof("Deleting messages sent on %v (%v time)", strings.Join(days, ", "), timezone)
+	}
+
+	if skipRepliedTo {
+		client.SetSkipRepliedTo(skipRepliedTo)
+		log.Info("Leaving messages that others have replied to alone, where the search shows the reply")
+	}
+
+	if planFile != "" {
+		err = client.SetPlanFile(planFile, planHash)
+		if err != nil {
+			fail(err)
+		}
+		if !dryrun {
+			log.Infof("Deleting exactly the messages in %v, stopping if anything else turns up", planFile)
+		}
+	}
+
+	if checkpoint != nil {
+		client.SetCheckpoint(checkpoint)
+		client.SetForceAccountMismatch(forceAccount)
+		log.Infof("Recording progress to %v", resumeFile)
+	}
+
 	if minAge > 0 {
 		err = client.SetMinAge(minAge)
 		if err != nil {
-			log.Fatal(err)
+			failUsage(err)
 		}
 		log.Infof("Deleting messages with a minimum age of %v days", minAge)
 	}
@@ -60,20 +575,120 @@ func partial(cmd *cobra.Command, args []string) {
 	if maxAge > 0 {
 		err = client.SetMaxAge(maxAge)
 		if err != nil {
-			log.Fatal(err)
+			failUsage(err)
 		}
 		log.Infof("Deleting messages with a maximum age of %v days", maxAge)
 	}
 
-	err = client.PartialDelete()
-	if err != nil {
-		log.Fatal(err)
+	if dormant != "" {
+		err = client.SetDormantOnly(dormant)
+		if err != nil {
+			failUsage(err)
+		}
+		log.Infof("Skipping channels with messages newer than %v", dormant)
 	}
+
+	if minID > 0 {
+		client.SetMinID(minID)
+		log.Infof("Deleting messages with a minimum ID of %v", minID)
+	}
+
+	if maxID > 0 {
+		client.SetMaxID(maxID)
+		log.Infof("Deleting messages with a maximum ID of %v", maxID)
+	}
+
+	return client, done
+}
+
+// createOutput creates a file written to as the run goes. Scheduled runs after the first
+// add to what the earlier ones wrote rather than starting it again.
+func createOutput(path string) (*os.File, error) {
+	if continuing {
+		return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	}
+	return os.Create(path)
 }
 
 func init() {
-	partialCmd.Flags().BoolVarP(&dryrun, "dry-run", "d", false, "perform dry run without deleting anything")
-	partialCmd.Flags().UintVarP(&minAge, "min-age-days", "i", 0, "minimum age in days of messages to delete")
-	partialCmd.Flags().UintVarP(&maxAge, "max-age-days", "a", 0, "maximum age in days of messages to delete")
-	partialCmd.Flags().StringSliceVarP(&skipChannels, "skip", "s", []string{}, "skip message deletion for specified channels/guilds")
+	addDeleteFlags(partialCmd)
+	partialCmd.Flags().DurationVar(&every, "every", 0, "repeat the run at this interval until nothing is left, deleting up to --limit messages each time")
+}
+
+func addDeleteFlags(cmd *cobra.Command) {
+	addScopeFlags(cmd)
+	cmd.Flags().BoolVarP(&dryrun, "dry-run", "d", false, "perform dry run without deleting anything")
+	cmd.Flags().StringVar(&onForbidden, "on-forbidden", "skip", "when denied permission to search a channel or delete a message, either skip it or fail")
+	cmd.Flags().BoolVar(&bestEffort, "best-effort", false, "continue past relationships that can't be resolved to a channel")
+	cmd.Flags().StringVar(&dormant, "dormant-only", "", "only delete from channels without any messages newer than this many days, e.g. 30d")
+	cmd.Flags().IntVar(&minLength, "min-length", 0, "minimum length in characters of messages to delete")
+	cmd.Flags().IntVar(&maxLength, "max-length", 0, "maximum length in characters of messages to delete")
+	cmd.Flags().StringSliceVar(&protect, "protect", []string{}, "never delete messages containing any of these words, whatever the other filters")
+	cmd.Flags().StringSliceVar(&mentions, "mentions", []string{}, "only delete messages mentioning specified user IDs, or everyone/here")
+	cmd.Flags().BoolVar(&webhooksOnly, "webhooks-only", false, "only delete messages sent through webhooks")
+	cmd.Flags().BoolVar(&repliesOnly, "replies-only", false, "only delete messages sent as replies")
+	cmd.Flags().BoolVar(&noReplies, "no-replies", false, "don't delete messages sent as replies")
+	cmd.Flags().BoolVar(&noWebhooks, "no-webhooks", false, "don't delete messages sent through webhooks")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "only delete from channels you've read up to the latest message, leaving unread ones alone")
+	cmd.Flags().BoolVar(&unreadOnly, "unread-only", false, "only delete from channels with messages you haven't read")
+	cmd.Flags().BoolVar(&skipRepliedTo, "skip-replied-to", false, "leave messages alone when someone else's reply to them is in the context the search returns (best effort)")
+	cmd.Flags().BoolVar(&editedOnly, "edited-only", false, "only delete messages which have been edited")
+	cmd.Flags().StringVar(&hours, "hours", "", "only delete messages sent within these hours of the day, e.g. 2-5 for 2am to 5am or 22-3 across midnight")
+	cmd.Flags().StringSliceVar(&days, "days", nil, "only delete messages sent on these days of the week, e.g. sat,sun")
+	cmd.Flags().StringVar(&timezone, "tz", "local", "timezone for --hours and --days, either local, UTC or a name like Europe/London")
+	cmd.Flags().StringVar(&startPhase, "start-phase", "channels", "phase to start from, either channels, relationships or guilds")
+	cmd.Flags().StringVar(&channelsFile, "channels-file", "", "only delete from the channel IDs listed in this file, one per line, without listing any other channels or guilds")
+	cmd.Flags().StringSliceVar(&only, "only", []string{}, "only delete from specified channel IDs, without listing any other channels or guilds")
+	cmd.Flags().StringVar(&category, "category", "", "only delete from channels under specified guild category ID")
+	cmd.Flags().StringVar(&channelName, "channel-name", "", "only delete from guild channels with specified name, in every guild")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "don't ask before deleting from each channel found by --channel-name, or before a run longer than --confirm-over")
+	cmd.Flags().BoolVar(&estimateOnly, "estimate", false, "estimate how many messages there are and how long deleting them would take, without deleting anything")
+	cmd.Flags().DurationVar(&confirmOver, "confirm-over", 0, "estimate how long the run will take first, asking before starting if it's longer than this")
+	cmd.Flags().BoolVar(&noSkipSystem, "no-skip-system", false, "delete from guild system, rules and public updates channels too, rather than skipping them")
+	cmd.Flags().IntVar(&emptyRetries, "empty-page-retries", 0, "times to retry an empty first page of a guild search, while the search index warms up")
+	cmd.Flags().BoolVar(&channelScan, "per-channel-guild-scan", false, "additionally search each guild channel individually to catch missed messages")
+	cmd.Flags().BoolVar(&countBeforeAfter, "count-before-after", false, "count your messages everywhere before and after the run and report the difference, at the cost of a search per DM and guild each time")
+	cmd.Flags().BoolVar(&strictFields, "strict-fields", false, "stop the run when Discord leaves out a field we rely on, rather than warning and carrying on")
+	cmd.Flags().IntVar(&serverRetries, "server-retries", 3, "times to retry a request after a server error, with an increasing delay")
+	cmd.Flags().DurationVar(&retryAfter, "max-retry-after", 5*time.Minute, "maximum time to sleep when rate limited by the server")
+	cmd.Flags().BoolVar(&forceAccount, "force-account-mismatch", false, "resume a --resume-file written for a different account")
+	cmd.Flags().BoolVar(&force, "force", false, "run even if a previous run left the account without any messages")
+	cmd.Flags().StringVar(&jobFile, "only-file", "", "only delete from the channels and guilds listed in a job file written by plan")
+	cmd.Flags().StringSliceVarP(&recipients, "recipient", "r", []string{}, "only delete messages in DMs with specified users, by ID, username#discriminator or username")
+	cmd.Flags().BoolVar(&cautious, "cautious", false, "check the account's flags before each channel, slowing down if Discord has flagged it")
+	cmd.Flags().IntVar(&logEvery, "log-every", 1, "only log every nth deleted message, along with the total so far")
+	cmd.Flags().Int64Var(&limit, "limit", 0, "maximum number of messages to delete in this run, combine with --resume-file to continue later")
+	cmd.Flags().Float64Var(&oldestPercent, "oldest-percent", 0, "only delete the oldest percentage of your messages in each channel or guild, e.g. 20 for the oldest fifth")
+	cmd.Flags().IntVar(&perChannel, "per-channel-limit", 0, "maximum number of messages to delete from each channel or guild, combine with --resume-file to continue later")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "delete this many messages at a time, sleeping for --batch-cooldown between batches")
+	cmd.Flags().DurationVar(&batchCooldown, "batch-cooldown", time.Minute, "time to sleep between batches of --batch-size messages")
+	cmd.Flags().DurationVar(&slowmodeDelay, "slowmode-delay", 0, "extra time to sleep between deletions in channels with slowmode, best-effort")
+	cmd.Flags().DurationVar(&cooldown, "channel-cooldown", 0, "time to sleep between channels, to spread requests out")
+	cmd.Flags().DurationVar(&networkWait, "wait-for-network", 0, "maximum time to pause for when the connection drops, rather than failing")
+	cmd.Flags().StringVar(&strategy, "strategy", "offset", "pagination strategy to use, either offset or maxid")
+	cmd.Flags().BoolVar(&logTypes, "log-message-types", false, "log undeletable messages in full and a table of message types seen")
+	cmd.Flags().StringVar(&planHash, "plan-hash", "", "the reviewed hash of the plan in --plan-file, which must match before anything is deleted")
+	cmd.Flags().StringVar(&planFile, "plan-file", "", "write what a dry run would delete to file, or make a real run delete exactly what's in it")
+	cmd.Flags().StringVar(&resumeFile, "resume-file", "", "record per-channel progress to file, resuming from it if it already exists")
+	cmd.Flags().StringVar(&timestampsCSV, "timestamps-csv", "", "write the ID, channel and time sent of each deleted message (or message that would be deleted in dry-run mode) to a CSV file")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write deleted messages (or messages that would be deleted in dry-run mode) to file")
+	cmd.Flags().StringVar(&archive, "archive-raw", "", "append every message in full, as returned by the search, to file before deleting it")
+	cmd.Flags().StringVar(&webhook, "notify-webhook", "", "POST a summary to a webhook URL once the run finishes or fails")
+	cmd.Flags().StringVar(&manifest, "manifest", "", "write a manifest mapping channel, guild and user IDs to names to file")
+}
+
+// addScopeFlags adds the flags narrowing down where to look for messages, shared with plan
+func addScopeFlags(cmd *cobra.Command) {
+	cmd.Flags().UintVarP(&minAge, "min-age-days", "i", 0, "minimum age in days of messages to delete")
+	cmd.Flags().UintVarP(&maxAge, "max-age-days", "a", 0, "maximum age in days of messages to delete")
+	cmd.Flags().Int64Var(&minID, "min-id", 0, "minimum snowflake ID of messages to delete")
+	cmd.Flags().Int64Var(&maxID, "max-id", 0, "maximum snowflake ID of messages to delete")
+	cmd.Flags().StringVar(&repliesTo, "replies-to", "", "only delete messages sent in reply to specified user ID")
+	cmd.Flags().BoolVar(&linksOnly, "links-only", false, "only delete messages containing links")
+	cmd.Flags().StringSliceVar(&guilds, "guild", []string{}, "only delete from specified guilds during the guilds phase")
+	cmd.Flags().StringSliceVarP(&skipChannels, "skip", "s", []string{}, "skip message deletion for specified channels/guilds")
+	cmd.Flags().BoolVar(&skipRelations, "skip-relationships", false, "don't resolve relationships to DM channels")
+	cmd.Flags().IntSliceVar(&relationTypes, "relationship-types", []int{}, "only resolve relationships of these types: 1 friend, 2 blocked, 3 incoming request, 4 outgoing request")
+	cmd.Flags().BoolVar(&noDedup, "no-relationship-dedup", false, "resolve relationships even when their DM is already open, e.g. with --start-phase relationships")
+	cmd.Flags().BoolVar(&noReopenDMs, "no-reopen-dms", false, "only delete from DMs that are already open, rather than reopening closed ones")
 }
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bufio"
+	"discord-delete/client"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEmptyToken(t *testing.T) {
+	os.Setenv("DISCORD_TOKEN", " ")
+	defer os.Unsetenv("DISCORD_TOKEN")
+
+	_, err := lookupToken()
+	assert.Equal(t, ErrorNoToken, err)
+	assert.Equal(t, exitToken, exitCode(err))
+}
+
+func TestEstimateRefusedForNarrowRuns(t *testing.T) {
+	estimateOnly = true
+	defer func() { estimateOnly = false }()
+
+	only = []string{"1"}
+	defer func() { only = nil }()
+
+	var c client.Client
+	start, err := checkEstimate(&c)
+	assert.False(t, start)
+	assert.Equal(t, ErrorEstimateScope, err)
+	assert.Equal(t, exitUsage, exitCode(err))
+}
+
+func TestEstimateOnlyBeforeFirstScheduledRun(t *testing.T) {
+	confirmOver = time.Hour
+	defer func() { confirmOver = 0 }()
+
+	// A zero client can't search, so this only starts if the estimate is skipped
+	continuing = true
+	defer func() { continuing = false }()
+
+	var c client.Client
+	start, err := checkEstimate(&c)
+	assert.True(t, start)
+	assert.Nil(t, err)
+}
+
+func TestUsageExitCode(t *testing.T) {
+	err := &usageError{client.ErrorInvalidDays}
+	assert.Equal(t, exitUsage, exitCode(err))
+	assert.Equal(t, client.ErrorInvalidDays.Error(), err.Error())
+}
+
+func TestPlanFlags(t *testing.T) {
+	// Only the flags plan honours, rather than accepting ones it would ignore
+	for _, name := range []string{"dry-run", "resume-file", "output", "force", "limit"} {
+		assert.Nil(t, planCmd.Flags().Lookup(name), name)
+	}
+	for _, name := range []string{"skip", "guild", "min-age-days", "no-reopen-dms"} {
+		assert.NotNil(t, planCmd.Flags().Lookup(name), name)
+		assert.NotNil(t, partialCmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestPromptPasswordPiped(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	defer r.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	// Nothing to hide when it isn't a terminal
+	w.WriteString("hunter2\n")
+	w.Close()
+	password, err := promptPassword(bufio.NewReader(r), "Password: ")
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", password)
+}
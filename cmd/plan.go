@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan <job file>",
+	Short: "Write a job file listing everywhere there are messages to delete, without deleting anything",
+	Args:  cobra.ExactArgs(1),
+	Run:   plan,
+}
+
+func plan(cmd *cobra.Command, args []string) {
+	c, done := newClient()
+	defer done()
+
+	job, err := c.Plan()
+	if err != nil {
+		fail(err)
+	}
+
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	err = client.WriteFileAtomic(args[0], append(data, '\n'), 0600)
+	if err != nil {
+		fail(err)
+	}
+
+	total := 0
+	for _, entry := range job.Entries {
+		total += entry.Estimate
+	}
+	log.Infof("Wrote %v entries with an estimated %v messages to %v, pass it to partial with --only-file", len(job.Entries), total, args[0])
+}
+
+func init() {
+	// Only what the search honours, the rest would be silently ignored
+	addScopeFlags(planCmd)
+}
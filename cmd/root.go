@@ -1,25 +1,138 @@
 package cmd
 
 import (
+	"discord-delete/client"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
 )
 
+// Every flag can also be set with an environment variable of the form
+// DISCORD_DELETE_<FLAG>, e.g. --dry-run becomes DISCORD_DELETE_DRY_RUN
+// Flags passed on the command line take precedence over the environment
+const envPrefix = "DISCORD_DELETE_"
+
 var (
-	verbose bool
-	rootCmd = &cobra.Command{
-		Use:   "discord-delete",
-		Short: "A tool to delete Discord message history",
+	verbose  bool
+	quiet    bool
+	caFile   string
+	insecure bool
+	headers  []string
+	rootCmd  = &cobra.Command{
+		Use:               "discord-delete",
+		Short:             "A tool to delete Discord message history",
+		PersistentPreRunE: setup,
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(partialCmd)
+	rootCmd.AddCommand(afterMessageCmd)
+	rootCmd.AddCommand(compareCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(listTypesCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(threadCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(whoamiCmd)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose logging")
+	rootCmd.PersistentFlags().StringVar(&caFile, "ca-file", "", "trust the certificates in file, for networks which intercept TLS")
+	rootCmd.PersistentFlags().StringArrayVar(&headers, "header", []string{}, "extra header to send with every request, like 'Name: value', can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "skip TLS certificate verification (dangerous)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log warnings and errors, followed by a final summary")
 }
 
 func Execute() {
+	// Errors reaching here come from cobra itself, i.e. bad arguments or flags
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		os.Exit(exitUsage)
+	}
+}
+
+func setup(cmd *cobra.Command, args []string) error {
+	err := bindEnv(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if verbose && quiet {
+		return fmt.Errorf("--verbose and --quiet can't be used together")
+	}
+	if verbose {
+		log.SetLevel(log.DebugLevel)
+	}
+	if quiet {
+		log.SetLevel(log.WarnLevel)
+	}
+
+	return nil
+}
+
+// configureBaseURL lets DISCORD_API_BASE point every command at a different API,
+// which is only meant for testing against a mock or staging environment
+func configureBaseURL(c *client.Client) {
+	base, def := os.LookupEnv("DISCORD_API_BASE")
+	if !def {
+		return
+	}
+
+	err := c.SetBaseURL(base)
+	if err != nil {
+		failUsage(err)
+	}
+	log.Warnf("Using the API at %v rather than Discord", base)
+}
+
+func configureHeaders(c *client.Client) {
+	if len(headers) == 0 {
+		return
+	}
+
+	err := c.SetHeaders(headers)
+	if err != nil {
+		failUsage(err)
+	}
+	log.Infof("Sending %v extra headers with every request", len(headers))
+}
+
+func configureTLS(c *client.Client) {
+	if insecure {
+		log.Warn("TLS certificate verification is disabled, anyone on your network could intercept your token")
+	}
+
+	err := c.SetTLSConfig(caFile, insecure)
+	if err != nil {
+		failUsage(err)
 	}
 }
+
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+func bindEnv(cmd *cobra.Command, args []string) error {
+	var err error
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed {
+			return
+		}
+
+		value, def := os.LookupEnv(envName(flag.Name))
+		if !def {
+			return
+		}
+
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("Invalid value for %v: %v", envName(flag.Name), setErr)
+		}
+	})
+
+	return err
+}
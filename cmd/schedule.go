@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"time"
+)
+
+var every time.Duration
+
+// continuing is set once the first scheduled run is over, so later runs add to its output
+var continuing bool
+
+// schedule repeats runs of up to --limit messages every interval, each carrying on from
+// the resume file, until a run finds nothing left to delete or is stopped from the keyboard
+func schedule() {
+	switch {
+	case limit == 0:
+		failUsage(errors.New("--every needs --limit, to say how many messages to delete each time"))
+	case resumeFile == "":
+		failUsage(errors.New("--every needs --resume-file, so that each run carries on from the last"))
+	case dryrun:
+		failUsage(errors.New("--every can't be used with --dry-run, since dry runs don't record any progress"))
+	}
+
+	var total int64
+	for cycle := 1; ; cycle++ {
+		c, done := newClient()
+		err := run(&c)
+		deleted := c.DeletedCount()
+		done()
+		continuing = true
+		if err != nil {
+			fail(err)
+		}
+		total += deleted
+
+		if keyboard != nil && keyboard.Stopped() {
+			log.Infof("Stopped after %v runs, %v messages deleted in total", cycle, total)
+			return
+		}
+
+		// A run which stopped short of the limit went through everything, unless the
+		// per-channel limit held it back, in which case only an empty run means we're done
+		if deleted == 0 || (perChannel == 0 && deleted < limit) {
+			log.Infof("Nothing left to delete after %v runs, %v messages deleted in total", cycle, total)
+			return
+		}
+
+		log.Infof("Run %v deleted %v messages, %v in total so far, next run at %v", cycle, deleted, total, time.Now().Add(every).Format(time.RFC3339))
+		if wait(every) != nil {
+			log.Infof("Interrupted after %v runs, %v messages deleted in total", cycle, total)
+			os.Exit(exitInterrupted)
+		}
+	}
+}
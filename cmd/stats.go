@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var statsJSON bool
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Count your messages in each open DM and guild without deleting anything",
+	Args:  cobra.NoArgs,
+	Run:   stats,
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the counts as JSON, along with the total")
+}
+
+func stats(cmd *cobra.Command, args []string) {
+	tok, err := lookupToken()
+	if err != nil {
+		fail(err)
+	}
+
+	c := client.New(tok)
+	configureTLS(&c)
+	configureBaseURL(&c)
+	configureHeaders(&c)
+
+	counts, err := c.MessageCounts()
+	if err != nil {
+		fail(err)
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count.Count
+	}
+
+	if statsJSON {
+		err = json.NewEncoder(os.Stdout).Encode(struct {
+			Counts []client.MessageCount `json:"counts"`
+			Total  int                   `json:"total"`
+		}{counts, total})
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	for _, count := range counts {
+		fmt.Printf("%8v  %-7v %v\n", count.Count, count.Kind, count.Name)
+	}
+	fmt.Printf("%8v  total\n", total)
+}
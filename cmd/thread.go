@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Set by the thread command, in place of searching everywhere
+var threadID string
+
+var threadCmd = &cobra.Command{
+	Use:   "thread <thread ID>",
+	Short: "Delete messages from a single thread, without scanning the rest of its guild",
+	Args:  cobra.ExactArgs(1),
+	Run:   thread,
+}
+
+func thread(cmd *cobra.Command, args []string) {
+	_, err := client.ParseSnowflake(args[0])
+	if err != nil {
+		failUsage(errors.Wrap(err, "Invalid thread ID"))
+	}
+	threadID = args[0]
+
+	c, done := newClient()
+	defer done()
+
+	err = run(&c)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	addDeleteFlags(threadCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+)
+
+var listTypesJSON bool
+
+var listTypesCmd = &cobra.Command{
+	Use:   "list-types",
+	Short: "Count the channels of each type on the account without deleting anything",
+	Args:  cobra.NoArgs,
+	Run:   listTypes,
+}
+
+func init() {
+	listTypesCmd.Flags().BoolVar(&listTypesJSON, "json", false, "print the counts as JSON, keyed by channel type")
+}
+
+func listTypes(cmd *cobra.Command, args []string) {
+	tok, err := lookupToken()
+	if err != nil {
+		fail(err)
+	}
+
+	c := client.New(tok)
+	configureTLS(&c)
+	configureBaseURL(&c)
+	configureHeaders(&c)
+
+	counts, err := c.ChannelTypes()
+	if err != nil {
+		fail(err)
+	}
+
+	if listTypesJSON {
+		err = json.NewEncoder(os.Stdout).Encode(counts)
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	var types []int
+	for channelType := range counts {
+		types = append(types, channelType)
+	}
+	sort.Ints(types)
+
+	for _, channelType := range types {
+		fmt.Printf("%-4v %-20v %v\n", channelType, client.ChannelTypeName(channelType), counts[channelType])
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"discord-delete/client"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Print the account the token belongs to without deleting anything",
+	Args:  cobra.NoArgs,
+	Run:   whoami,
+}
+
+func whoami(cmd *cobra.Command, args []string) {
+	tok, err := lookupToken()
+	if err != nil {
+		fail(err)
+	}
+
+	c := client.New(tok)
+	configureTLS(&c)
+	configureBaseURL(&c)
+	configureHeaders(&c)
+
+	me, err := c.Me()
+	if err != nil {
+		fail(errors.Wrap(err, "Error fetching profile information"))
+	}
+
+	fmt.Printf("Logged in as %v (%v)\n", me, me.ID)
+	if me.GlobalName != "" {
+		fmt.Printf("Display name: %v\n", me.GlobalName)
+	}
+	if me.Email != "" {
+		fmt.Printf("Email: %v\n", me.Email)
+	}
+}